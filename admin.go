@@ -0,0 +1,377 @@
+package flow
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// adminKeys are the keys an operator may present as "Authorization: Bearer
+// <key>" to use the admin API, from FLOW_ADMIN_KEYS (comma-separated).
+// Unset disables the admin API.
+var adminKeys = SplitList(os.Getenv("FLOW_ADMIN_KEYS"))
+
+// validAdminKey reports whether key is one of adminKeys, constant-time per
+// key like validBotKey.
+func validAdminKey(key string) bool {
+	ok := false
+	for _, k := range adminKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
+
+// AdminAction is what an AdminRequest asks the playfield to do.
+type AdminAction string
+
+const (
+	AdminInspect     AdminAction = "inspect"      // just report the room
+	AdminKick        AdminAction = "kick"         // take WormId off the field
+	AdminKickToken   AdminAction = "kick_token"   // take Token's worm off the field, if here
+	AdminRename      AdminAction = "rename"       // rename WormId to Name
+	AdminClearFood   AdminAction = "clear_food"   // remove every food
+	AdminRespawnFood AdminAction = "respawn_food" // replace every food with a fresh spread
+	AdminPacMan      AdminAction = "pacman"       // Enabled turns Pac-Man on or off
+	AdminBots        AdminAction = "bots"         // set Rules.Bots to Count
+)
+
+// AdminRequest is queued by AdminHandler for an operator's action. The
+// playfield goroutine applies it, like any other state change, and answers
+// on Reply with the room as it stands afterwards.
+type AdminRequest struct {
+	Action  AdminAction
+	WormId  Id
+	Token   string
+	Name    string
+	Enabled bool
+	Count   int
+	Reply   chan AdminReply
+}
+
+type AdminReply struct {
+	Room RoomInfo
+	Err  error
+}
+
+// RoomInfo is a playfield as the admin API shows it.
+type RoomInfo struct {
+	Room string
+	Seed uint64
+	// Ticks is how many ticks the room has run.
+	Ticks  int
+	Humans int
+	// Bots counts server AI and bot API worms alike.
+	Bots   int
+	Foods  int
+	PacMen int
+	// PacMan and BotCount are the room's current settings: whether
+	// Pac-Men spawn, and Rules.Bots.
+	PacMan   bool
+	BotCount int
+	// ShedLevel is how much load the room is shedding (see load.go).
+	ShedLevel int
+	Worms     []WormInfo
+}
+
+// WormInfo is one worm in RoomInfo. Token is the session secret; it is
+// what a ban names.
+type WormInfo struct {
+	Id        Id
+	Name      string
+	Token     string
+	PlayerId  string
+	AI        bool
+	Bot       bool
+	Connected bool
+	Dead      bool
+	Score     int
+	Length    int
+}
+
+var errNoWorm = errors.New("no such worm")
+
+// admin applies req. Runs on the playfield goroutine.
+func (p *Playfield) admin(req AdminRequest) AdminReply {
+	worm := func() (*Worm, error) {
+		for m, id := range p.Movables {
+			if w, ok := m.(*Worm); ok && id == req.WormId {
+				return w, nil
+			}
+		}
+		return nil, errNoWorm
+	}
+	switch req.Action {
+	case AdminInspect:
+	case AdminKick:
+		w, err := worm()
+		if err != nil {
+			return AdminReply{Err: err}
+		}
+		p.removeMovable(w)
+		p.reconcilePopulation()
+	case AdminKickToken:
+		if w, ok := p.Tokens[req.Token]; ok {
+			p.removeMovable(w)
+			p.reconcilePopulation()
+		}
+	case AdminRename:
+		w, err := worm()
+		if err != nil {
+			return AdminReply{Err: err}
+		}
+		name := sanitizeName(req.Name)
+		if name == "" {
+			return AdminReply{Err: errors.New("name must not be empty")}
+		}
+		p.rename(w, name)
+	case AdminClearFood, AdminRespawnFood:
+		for _, f := range p.sortedFoods() {
+			delete(p.Foods, f.Id)
+			p.Broadcast <- Packet{Command: "FOOD_EXPIRE", Payload: FoodExpirePayload{FoodId: f.Id}}
+		}
+		if req.Action == AdminRespawnFood {
+			for p.regularFoodCount() < p.Rules.FoodCount {
+				f, ok := p.spawnFood()
+				if !ok {
+					break
+				}
+				p.Broadcast <- p.foodPacket(f)
+			}
+		}
+	case AdminPacMan:
+		p.Rules.NoPacMan = !req.Enabled
+		p.reconcilePacMan()
+	case AdminBots:
+		if req.Count < AutoBots || req.Count > MaxBots {
+			return AdminReply{Err: errors.New("bot count must be -1 (automatic) to " + strconv.Itoa(MaxBots))}
+		}
+		p.Rules.Bots = req.Count
+		p.reconcilePopulation()
+	default:
+		return AdminReply{Err: errors.New("unknown admin action")}
+	}
+	return AdminReply{Room: p.roomInfo()}
+}
+
+// roomInfo describes the playfield for the admin API.
+func (p *Playfield) roomInfo() RoomInfo {
+	info := RoomInfo{
+		Room:      p.Room,
+		Seed:      p.Seed,
+		Ticks:     p.ticks,
+		Foods:     len(p.Foods),
+		PacMen:    len(p.pacmen),
+		PacMan:    !p.Rules.NoPacMan,
+		BotCount:  p.Rules.Bots,
+		ShedLevel: p.shedLevel,
+		Worms:     []WormInfo{},
+	}
+	for _, m := range p.sortedMovables() {
+		w, ok := m.(*Worm)
+		if !ok {
+			continue
+		}
+		if isHuman(w) {
+			info.Humans++
+		} else {
+			info.Bots++
+		}
+		wi := WormInfo{
+			Id:        p.Movables[m],
+			Name:      w.Name,
+			Token:     w.Token,
+			AI:        w.AI,
+			Bot:       w.Bot,
+			Connected: w.connected,
+			Dead:      w.killed,
+			Score:     w.Score,
+			Length:    len(w.blocks),
+		}
+		if !w.AI {
+			wi.PlayerId = PlayerId(w.Token)
+		}
+		info.Worms = append(info.Worms, wi)
+	}
+	return info
+}
+
+// bans are the tokens refused at HELLO; see AdminHandler.
+var bans = map[string]bool{}
+
+// banned reports whether token has been banned.
+func banned(token string) bool {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+	return bans[token]
+}
+
+// adminRequest hands req to p's goroutine and waits for the answer.
+func adminRequest(p *Playfield, req AdminRequest) AdminReply {
+	req.Reply = make(chan AdminReply, 1)
+	p.Admin <- req
+	return <-req.Reply
+}
+
+// existingPlayfields returns the lobby's playfields by room, without
+// creating any.
+func existingPlayfields() map[string]*Playfield {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+	out := make(map[string]*Playfield, len(lobby.Playfields))
+	for key, p := range lobby.Playfields {
+		out[key] = p
+	}
+	return out
+}
+
+// AdminHandler serves the admin API under /admin/. Every request needs
+// "Authorization: Bearer <key>" with one of FLOW_ADMIN_KEYS. Rooms are
+// changed only through AdminRequests to their playfield goroutine. Bans
+// live only in memory, under lobby.mu, and are lost on restart.
+//
+//	GET    /admin/rooms                           every room, without worms
+//	GET    /admin/rooms/{room}                    one room and its worms
+//	POST   /admin/rooms/{room}/worms/{id}/kick
+//	POST   /admin/rooms/{room}/worms/{id}/rename  {"Name": "..."}
+//	POST   /admin/rooms/{room}/food/clear
+//	POST   /admin/rooms/{room}/food/respawn
+//	PUT    /admin/rooms/{room}/pacman             {"Enabled": false}
+//	PUT    /admin/rooms/{room}/bots               {"Count": 2}, -1 for automatic
+//	GET    /admin/bans
+//	POST   /admin/bans                            {"Token": "..."}, kicks it everywhere
+//	DELETE /admin/bans/{token}
+func AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/rooms", func(w http.ResponseWriter, r *http.Request) {
+		fields := existingPlayfields()
+		rooms := []RoomInfo{}
+		for _, p := range fields {
+			info := adminRequest(p, AdminRequest{Action: AdminInspect}).Room
+			info.Worms = nil
+			rooms = append(rooms, info)
+		}
+		sort.Slice(rooms, func(i, j int) bool { return rooms[i].Room < rooms[j].Room })
+		writeJSON(w, rooms)
+	})
+	room := func(action AdminAction, body func(*http.Request, *AdminRequest) error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			p, ok := existingPlayfields()[r.PathValue("room")]
+			if !ok {
+				http.Error(w, "no such room", http.StatusNotFound)
+				return
+			}
+			req := AdminRequest{Action: action}
+			if id := r.PathValue("id"); id != "" {
+				n, err := strconv.ParseUint(id, 10, 64)
+				if err != nil {
+					http.Error(w, "worm id must be a number", http.StatusBadRequest)
+					return
+				}
+				req.WormId = Id(n)
+			}
+			if body != nil {
+				if err := body(r, &req); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			reply := adminRequest(p, req)
+			switch {
+			case errors.Is(reply.Err, errNoWorm):
+				http.Error(w, reply.Err.Error(), http.StatusNotFound)
+			case reply.Err != nil:
+				http.Error(w, reply.Err.Error(), http.StatusBadRequest)
+			default:
+				writeJSON(w, reply.Room)
+			}
+		}
+	}
+	mux.Handle("GET /admin/rooms/{room}", room(AdminInspect, nil))
+	mux.Handle("POST /admin/rooms/{room}/worms/{id}/kick", room(AdminKick, nil))
+	mux.Handle("POST /admin/rooms/{room}/worms/{id}/rename", room(AdminRename, func(r *http.Request, req *AdminRequest) error {
+		var body struct{ Name string }
+		err := json.NewDecoder(r.Body).Decode(&body)
+		req.Name = body.Name
+		return err
+	}))
+	mux.Handle("POST /admin/rooms/{room}/food/clear", room(AdminClearFood, nil))
+	mux.Handle("POST /admin/rooms/{room}/food/respawn", room(AdminRespawnFood, nil))
+	mux.Handle("PUT /admin/rooms/{room}/pacman", room(AdminPacMan, func(r *http.Request, req *AdminRequest) error {
+		var body struct{ Enabled bool }
+		err := json.NewDecoder(r.Body).Decode(&body)
+		req.Enabled = body.Enabled
+		return err
+	}))
+	mux.Handle("PUT /admin/rooms/{room}/bots", room(AdminBots, func(r *http.Request, req *AdminRequest) error {
+		var body struct{ Count *int }
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return err
+		}
+		if body.Count == nil {
+			return errors.New("Count is required")
+		}
+		req.Count = *body.Count
+		return nil
+	}))
+
+	mux.HandleFunc("GET /admin/bans", func(w http.ResponseWriter, r *http.Request) {
+		lobby.mu.Lock()
+		list := make([]string, 0, len(bans))
+		for token := range bans {
+			list = append(list, token)
+		}
+		lobby.mu.Unlock()
+		sort.Strings(list)
+		writeJSON(w, list)
+	})
+	mux.HandleFunc("POST /admin/bans", func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Token string }
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Token == "" {
+			http.Error(w, "Token is required", http.StatusBadRequest)
+			return
+		}
+		if isAIToken(body.Token) {
+			http.Error(w, "bots can't be banned; set the room's bot count instead", http.StatusBadRequest)
+			return
+		}
+		lobby.mu.Lock()
+		bans[body.Token] = true
+		lobby.mu.Unlock()
+		for _, p := range existingPlayfields() {
+			adminRequest(p, AdminRequest{Action: AdminKickToken, Token: body.Token})
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /admin/bans/{token}", func(w http.ResponseWriter, r *http.Request) {
+		lobby.mu.Lock()
+		delete(bans, r.PathValue("token"))
+		lobby.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(adminKeys) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !validAdminKey(key) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,136 @@
+package flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// adminRoom starts a playfield with one connected human and puts it in
+// the lobby under room for the admin API to find.
+func adminRoom(t *testing.T, room string) (*Playfield, AttachReply) {
+	p := NewPlayfield()
+	p.Room = room
+	p.Start()
+	reply := make(chan AttachReply, 1)
+	p.Attach <- AttachRequest{Token: "tok-" + room, Name: "ada", Reply: reply}
+	attached := <-reply
+	p.ConnState <- ConnState{Worm: attached.Worm, Connected: true}
+	// The loop picks among ready channels at random; wait until it has
+	// taken the ConnState and topped the room up with bots.
+	for i := 0; i < 100 && adminRequest(p, AdminRequest{Action: AdminInspect}).Room.Bots == 0; i++ {
+	}
+
+	lobby.mu.Lock()
+	lobby.Playfields[room] = p
+	lobby.mu.Unlock()
+	t.Cleanup(func() {
+		lobby.mu.Lock()
+		delete(lobby.Playfields, room)
+		lobby.mu.Unlock()
+		p.Stop()
+	})
+	return p, attached
+}
+
+func adminCall(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	AdminHandler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminNeedsKey(t *testing.T) {
+	defer func(old []string) { adminKeys = old }(adminKeys)
+	adminKeys = nil
+	if rec := adminCall(t, "GET", "/admin/rooms", ""); rec.Code != 404 {
+		t.Errorf("Expected the admin API off without keys, got %d", rec.Code)
+	}
+	adminKeys = []string{"other"}
+	if rec := adminCall(t, "GET", "/admin/rooms", ""); rec.Code != 401 {
+		t.Errorf("Expected a wrong key refused, got %d", rec.Code)
+	}
+}
+
+func TestAdminManagesRoom(t *testing.T) {
+	defer func(old []string) { adminKeys = old }(adminKeys)
+	adminKeys = []string{"secret"}
+	_, ada := adminRoom(t, "admin-room")
+
+	var info RoomInfo
+	rec := adminCall(t, "GET", "/admin/rooms/admin-room", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Bad room JSON %q: %v", rec.Body.String(), err)
+	}
+	if info.Humans != 1 || info.Bots != MinPlayers-1 || !info.PacMan || info.PacMen != 1 {
+		t.Errorf("Unexpected room: %+v", info)
+	}
+
+	rec = adminCall(t, "POST", fmt.Sprintf("/admin/rooms/admin-room/worms/%d/rename", ada.Id), `{"Name": "bob"}`)
+	json.Unmarshal(rec.Body.Bytes(), &info)
+	if info.Worms[0].Name != "bob" {
+		t.Errorf("Expected the rename applied, got %+v", info.Worms[0])
+	}
+	if rec := adminCall(t, "POST", "/admin/rooms/admin-room/worms/999/kick", ""); rec.Code != 404 {
+		t.Errorf("Expected an unknown worm to 404, got %d", rec.Code)
+	}
+
+	rec = adminCall(t, "PUT", "/admin/rooms/admin-room/pacman", `{"Enabled": false}`)
+	json.Unmarshal(rec.Body.Bytes(), &info)
+	if info.PacMan || info.PacMen != 0 {
+		t.Errorf("Expected Pac-Man gone, got %+v", info)
+	}
+	rec = adminCall(t, "PUT", "/admin/rooms/admin-room/bots", `{"Count": 0}`)
+	json.Unmarshal(rec.Body.Bytes(), &info)
+	if info.Bots != 0 || info.BotCount != 0 {
+		t.Errorf("Expected the bots gone, got %+v", info)
+	}
+	if rec := adminCall(t, "PUT", "/admin/rooms/admin-room/bots", `{"Count": 99}`); rec.Code != 400 {
+		t.Errorf("Expected too many bots refused, got %d", rec.Code)
+	}
+
+	rec = adminCall(t, "POST", "/admin/rooms/admin-room/food/clear", "")
+	json.Unmarshal(rec.Body.Bytes(), &info)
+	if info.Foods != 0 {
+		t.Errorf("Expected no food, got %d", info.Foods)
+	}
+	rec = adminCall(t, "POST", "/admin/rooms/admin-room/food/respawn", "")
+	json.Unmarshal(rec.Body.Bytes(), &info)
+	if info.Foods != FoodCount {
+		t.Errorf("Expected a fresh spread of %d foods, got %d", FoodCount, info.Foods)
+	}
+}
+
+func TestAdminBanKicksAndRefuses(t *testing.T) {
+	defer func(old []string) { adminKeys = old }(adminKeys)
+	adminKeys = []string{"secret"}
+	_, ada := adminRoom(t, "ban-room")
+	defer func() {
+		lobby.mu.Lock()
+		delete(bans, ada.Worm.Token)
+		lobby.mu.Unlock()
+	}()
+
+	if rec := adminCall(t, "POST", "/admin/bans", `{"Token": "Bot-Sly"}`); rec.Code != 400 {
+		t.Errorf("Expected a bot token refused, got %d", rec.Code)
+	}
+	if rec := adminCall(t, "POST", "/admin/bans", `{"Token": "`+ada.Worm.Token+`"}`); rec.Code != 204 {
+		t.Fatalf("Expected the ban accepted, got %d", rec.Code)
+	}
+	if !banned(ada.Worm.Token) {
+		t.Error("Expected the token banned")
+	}
+	var info RoomInfo
+	json.Unmarshal(adminCall(t, "GET", "/admin/rooms/ban-room", "").Body.Bytes(), &info)
+	if info.Humans != 0 {
+		t.Errorf("Expected the banned worm kicked, got %+v", info)
+	}
+	adminCall(t, "DELETE", "/admin/bans/"+ada.Worm.Token, "")
+	if banned(ada.Worm.Token) {
+		t.Error("Expected the ban lifted")
+	}
+}
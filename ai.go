@@ -33,6 +33,9 @@ type AIPersonality struct {
 	// direction. When Pac-Man is far the term is zero; up close it
 	// dominates food pull so the bot actually runs instead of munching.
 	PacManFear float64
+	// Lookahead makes the bot refuse moves into a region too small for
+	// its body (see roomyDirections), like the planner does.
+	Lookahead bool
 }
 
 // PacManFearRadius is how close (manhattan) Pac-Man has to be for a bot
@@ -40,91 +43,152 @@ type AIPersonality struct {
 // this distance, food pull / inertia / center-pull are the only inputs.
 const PacManFearRadius = 8
 
-// newPersonality draws a fresh persona from a random seed. The seed becomes
-// the bot's identity (name) so the same seed always produces the same player.
-func newPersonality() AIPersonality {
-	seed := rand.Uint64()
+// BlastFear is the penalty per cell a candidate move sits inside an armed
+// bomb's blast radius (plus one, so bots also keep off its edge). It is
+// not part of the personality: no bot is brave about explosions.
+const BlastFear = 4.0
+
+// newPersonality draws a fresh persona at difficulty d from a seed taken
+// off rng.
+func newPersonality(rng *rand.Rand, d Difficulty) AIPersonality {
+	return personalityFor(rng.Uint64(), d)
+}
+
+// personalityFor derives the persona for seed at difficulty d. The seed
+// becomes the bot's identity (name) so the same seed always produces the
+// same player, and re-deriving it at another difficulty retunes the bot
+// without renaming it.
+func personalityFor(seed uint64, d Difficulty) AIPersonality {
+	preset := d.Preset()
 	r := rand.New(rand.NewPCG(seed, seed^0x9E3779B97F4A7C15))
 	return AIPersonality{
 		Seed:           seed,
 		Name:           fmt.Sprintf("Bot-%04X", uint16(seed)),
-		FoodPull:       0.8 + r.Float64()*2.0,   // 0.8 – 2.8
-		Inertia:        0.5 + r.Float64()*2.5,   // 0.5 – 3.0
-		CenterPull:     r.Float64() * 0.5,       // 0.0 – 0.5
-		HesitationRate: 0.03 + r.Float64()*0.07, // 3% – 10%
-		MistakeRate:    0.02 + r.Float64()*0.05, // 2% – 7%
-		PacManFear:     0.6 + r.Float64()*0.6,   // 0.6 – 1.2
+		FoodPull:       preset.FoodPull.draw(r),
+		Inertia:        preset.Inertia.draw(r),
+		CenterPull:     preset.CenterPull.draw(r),
+		HesitationRate: preset.HesitationRate.draw(r),
+		MistakeRate:    preset.MistakeRate.draw(r),
+		PacManFear:     preset.PacManFear.draw(r),
+		Lookahead:      preset.Lookahead,
 	}
 }
 
-// pickAIDirection scores each safe direction by the bot's personality and
-// picks the best — with a small chance of choosing the runner-up so two
-// bots in similar spots don't always make identical decisions.
+// pickAIDirection asks the bot's Strategy (see strategy.go) for its next
+// heading. Bots without a known strategy use DefaultStrategy.
 func pickAIDirection(w *Worm, p *Playfield) Direction {
-	personality := w.personality
-	if personality.Name == "" {
-		personality = newPersonality()
+	s, ok := LookupStrategy(w.strategy)
+	if !ok {
+		s, _ = LookupStrategy(DefaultStrategy)
 	}
+	return s.Decide(&View{w: w, p: p})
+}
+
+// classicStrategy scores each safe direction by the bot's personality and
+// picks the best — with a small chance of choosing the runner-up so two
+// bots in similar spots don't always make identical decisions.
+func classicStrategy(v *View) Direction {
+	w, p := v.w, v.p
+	personality := v.Personality()
 
 	candidates := safeDirections(w, p)
+	if personality.Lookahead {
+		candidates = roomyDirections(w, p, candidates)
+	}
 	// Occasionally the bot "doesn't see" an opponent body and may pick a
 	// direction that walks into one — that's the human-like mistake we
 	// want. Own-body and 180° reversals are still filtered, so the bot
 	// never makes an unforced error.
-	if rand.Float64() < personality.MistakeRate {
-		risky := bodyOblivousDirections(w)
+	if p.rng.Float64() < personality.MistakeRate {
+		risky := bodyOblivousDirections(w, p)
 		if len(risky) > 0 {
 			candidates = risky
 		}
 	}
-	if len(candidates) == 0 {
+	ranked := rankDirections(w, p, personality, candidates)
+	if len(ranked) == 0 {
 		return w.direction
 	}
 
-	target, hasTarget := nearestFood(w.Head(), p)
-	curFoodDist := 0
-	if hasTarget {
-		curFoodDist = manhattan(w.Head(), target)
+	// Hesitation: occasionally pick the runner-up instead of the best.
+	if len(ranked) > 1 && p.rng.Float64() < personality.HesitationRate {
+		return ranked[1]
 	}
+	return ranked[0]
+}
 
+// greedyStrategy uses the same scoring as classicStrategy without the
+// human touches: it never hesitates and never overlooks a body.
+func greedyStrategy(v *View) Direction {
+	ranked := rankDirections(v.w, v.p, v.Personality(), safeDirections(v.w, v.p))
+	if len(ranked) == 0 {
+		return v.w.direction
+	}
+	return ranked[0]
+}
+
+// rankDirections orders candidates best first by the personality's
+// weighting of food, inertia, centre pull, Pac-Man and armed bombs.
+func rankDirections(w *Worm, p *Playfield, personality AIPersonality, candidates []Direction) []Direction {
 	type scored struct {
 		dir   Direction
 		score float64
 	}
-	// Pac-Man's predicted next anchor — what the bot has to evade. We
-	// use the predicted, not current, position because by the time the
+
+	target, hasTarget := nearestFood(w.Head(), p)
+	curFoodDist := 0
+	if hasTarget {
+		curFoodDist = p.distance(w.Head(), target)
+	}
+
+	// Each Pac-Man's predicted next anchor — what the bot has to evade.
+	// We use the predicted, not current, position because by the time the
 	// bot's move lands Pac-Man has already moved one cell too.
-	var pacNext Position
-	havePacMan := false
-	if p.pacman != nil {
-		pacNext = p.pacman.pos
-		if p.pacman.direction != Unknown {
-			pacNext = wrap(step(p.pacman.pos, p.pacman.direction))
+	pacNexts := make([]Position, len(p.pacmen))
+	for i, pm := range p.pacmen {
+		pacNexts[i] = pm.pos
+		if pm.direction != Unknown {
+			pacNexts[i] = p.advance(pm.pos, pm.direction)
 		}
-		havePacMan = true
 	}
 
 	scores := make([]scored, 0, len(candidates))
 	for _, d := range candidates {
-		next := wrap(step(w.Head(), d))
+		next := p.advance(w.Head(), d)
 		s := 0.0
 		if hasTarget {
 			// Reward directions that close the gap. Diminishing return on
 			// distance avoids the bot wildly cornering for far-away food.
-			s += personality.FoodPull * float64(curFoodDist-manhattan(next, target))
+			s += personality.FoodPull * float64(curFoodDist-p.distance(next, target))
 		}
 		if d == w.direction && w.direction != Unknown {
 			s += personality.Inertia
 		}
 		s -= personality.CenterPull * float64(manhattan(next, Position{Boundary / 2, Boundary / 2}))
 		// Fear of Pac-Man: subtract a penalty proportional to how
-		// deep `next` sits inside the fear radius. Zero outside the
-		// radius so distant Pac-Man activity doesn't twitch the bot
-		// off its food path.
-		if havePacMan {
-			dPm := manhattan(next, pacNext)
+		// deep `next` sits inside each hunter's fear radius. Zero
+		// outside the radius so distant Pac-Man activity doesn't twitch
+		// the bot off its food path. A frightened Pac-Man is prey, so
+		// the same term turns into a pull.
+		for i, pm := range p.pacmen {
+			dPm := p.distance(next, pacNexts[i])
 			if dPm < PacManFearRadius {
-				s -= personality.PacManFear * float64(PacManFearRadius-dPm)
+				pull := personality.PacManFear * float64(PacManFearRadius-dPm)
+				if pm.Frightened() {
+					s += pull
+				} else {
+					s -= pull
+				}
+			}
+		}
+		// Armed bombs: get out of the blast radius before it goes off.
+		for _, f := range p.sortedFoods() {
+			if !f.Armed() {
+				continue
+			}
+			kind, _ := p.Rules.Foods.Kind(f.Type)
+			if dB := manhattan(next, f.Position); dB <= kind.BlastRadius {
+				s -= BlastFear * float64(kind.BlastRadius+1-dB)
 			}
 		}
 		scores = append(scores, scored{d, s})
@@ -141,19 +205,18 @@ func pickAIDirection(w *Worm, p *Playfield) Direction {
 		}
 	}
 
-	// Hesitation: occasionally pick the runner-up instead of the best.
-	pick := 0
-	if len(order) > 1 && rand.Float64() < personality.HesitationRate {
-		pick = 1
+	out := make([]Direction, len(order))
+	for i, o := range order {
+		out[i] = scores[o].dir
 	}
-	return scores[order[pick]].dir
+	return out
 }
 
 // bodyOblivousDirections returns directions that filter out only the
 // "unforced error" cases: own body and 180° reversal. Opponent bodies and
 // heads are NOT filtered — used when the bot is having a brain-fart so it
 // can plausibly walk into another snake.
-func bodyOblivousDirections(w *Worm) []Direction {
+func bodyOblivousDirections(w *Worm, p *Playfield) []Direction {
 	head := w.Head()
 	blocked := map[Position]struct{}{}
 	for i, b := range w.blocks {
@@ -167,7 +230,7 @@ func bodyOblivousDirections(w *Worm) []Direction {
 		if opposite(d) == w.direction && w.direction != Unknown {
 			continue
 		}
-		next := wrap(step(head, d))
+		next := p.advance(head, d)
 		if _, hit := blocked[next]; hit {
 			continue
 		}
@@ -184,6 +247,24 @@ func bodyOblivousDirections(w *Worm) []Direction {
 // field wraps so edges aren't unsafe.
 func safeDirections(w *Worm, p *Playfield) []Direction {
 	head := w.Head()
+	blocked := hazards(w, p)
+	out := make([]Direction, 0, 4)
+	for _, d := range []Direction{Up, Down, Left, Right} {
+		if opposite(d) == w.direction && w.direction != Unknown {
+			continue
+		}
+		next := p.advance(head, d)
+		if _, hit := blocked[next]; hit {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// hazards is the set of cells that would kill w's head next tick: see
+// safeDirections for what counts.
+func hazards(w *Worm, p *Playfield) map[Position]struct{} {
 	blocked := map[Position]struct{}{}
 	for i, b := range w.blocks {
 		if w.pendingGrowth == 0 && i == len(w.blocks)-1 {
@@ -207,34 +288,27 @@ func safeDirections(w *Worm, p *Playfield) []Direction {
 		// Predicted next-head cell — head-on with another head still
 		// kills both, so dodge if we can.
 		if ow.direction != Unknown {
-			blocked[wrap(step(ow.Head(), ow.direction))] = struct{}{}
+			blocked[p.advance(ow.Head(), ow.direction)] = struct{}{}
 		}
 	}
-	// Pac-Man's current footprint is bite territory if we step into any of
-	// it; his predicted-next footprint covers the head-on-swap case.
-	if p.pacman != nil {
-		for _, c := range p.pacman.Footprint() {
+	// Each Pac-Man's current footprint is bite territory if we step into
+	// any of it; his predicted-next footprint covers the head-on-swap case.
+	// A frightened Pac-Man can't bite, so he blocks nothing.
+	for _, pm := range p.pacmen {
+		if pm.Frightened() {
+			continue
+		}
+		for _, c := range pm.Footprint() {
 			blocked[c] = struct{}{}
 		}
-		if p.pacman.direction != Unknown {
-			nextAnchor := wrap(step(p.pacman.pos, p.pacman.direction))
+		if pm.direction != Unknown {
+			nextAnchor := p.advance(pm.pos, pm.direction)
 			for _, c := range footprintAt(nextAnchor) {
 				blocked[c] = struct{}{}
 			}
 		}
 	}
-	out := make([]Direction, 0, 4)
-	for _, d := range []Direction{Up, Down, Left, Right} {
-		if opposite(d) == w.direction && w.direction != Unknown {
-			continue
-		}
-		next := wrap(step(head, d))
-		if _, hit := blocked[next]; hit {
-			continue
-		}
-		out = append(out, d)
-	}
-	return out
+	return blocked
 }
 
 // wrap normalises a cell position onto the toroidal playfield.
@@ -284,19 +358,30 @@ func nearestFood(from Position, p *Playfield) (Position, bool) {
 	var best Position
 	bestEffective := 0
 	found := false
-	for _, f := range p.Foods {
-		if f.Type == Bomb {
+	for _, f := range p.sortedFoods() {
+		kind, _ := p.Rules.Foods.Kind(f.Type)
+		if kind.Lethal {
 			// Bots don't deliberately chase bombs (those are hazards, not
 			// rewards). They can still wander onto one — the safeDirections
 			// check doesn't filter bombs because the bomb cell behaves like
 			// any other unoccupied cell from a pathing standpoint.
 			continue
 		}
+		dist := p.distance(from, f.Position)
+		if f.expiresAt != 0 && f.expiresAt-p.ticks <= dist {
+			// It will be gone before we get there.
+			continue
+		}
 		// Effective distance lets us pull bots toward high-value food
 		// without ignoring something close. Broccoli's attraction bonus
-		// (see AIFoodAttraction) lets bots prefer it over a nearby apple
-		// when it's within a handful of cells of equal distance.
-		eff := manhattan(from, f.Position) - AIFoodAttraction[f.Type]
+		// (see FoodKind.Attraction) lets bots prefer it over a nearby apple
+		// when it's within a handful of cells of equal distance. Fleeing
+		// food gains a cell on us every Flee ticks, so the chase is longer
+		// than the distance alone.
+		eff := dist - kind.Attraction
+		if kind.Flee > 0 {
+			eff += dist / kind.Flee
+		}
 		if !found || eff < bestEffective {
 			bestEffective = eff
 			best = f.Position
@@ -307,7 +392,8 @@ func nearestFood(from Position, p *Playfield) (Position, bool) {
 }
 
 // manhattan is wrap-aware: the field is a torus so distance along each axis
-// is the shorter of going direct or wrapping around the edge.
+// is the shorter of going direct or wrapping around the edge. It ignores
+// portals; anything that plans a route wants Playfield.distance instead.
 func manhattan(a, b Position) int {
 	dx := abs(a.X - b.X)
 	if w := (Boundary + 1) - dx; w < dx {
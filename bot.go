@@ -0,0 +1,91 @@
+package flow
+
+import (
+	"crypto/subtle"
+	"log"
+	"os"
+	"time"
+)
+
+// BotMoveDeadline is the default for how long an external bot has, after
+// its STATE frame is sent, to answer with MOVE. Later answers are dropped
+// and the worm keeps its heading. Shorter than Tick so an answer always
+// lands before the next step.
+const BotMoveDeadline = 150 * time.Millisecond
+
+// botKeys are the keys external programs may present in HELLO to play as
+// bots, from FLOW_BOT_KEYS (comma-separated). Unset disables the bot API.
+var botKeys = SplitList(os.Getenv("FLOW_BOT_KEYS"))
+
+// validBotKey reports whether key is one of botKeys. Constant-time per key
+// so the comparison doesn't leak how much of a key was right.
+func validBotKey(key string) bool {
+	ok := false
+	for _, k := range botKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
+
+// statePacket is the full-state frame an external bot gets every tick: all
+// it needs to decide a move without tracking deltas itself.
+func (p *Playfield) statePacket(you Id) Packet {
+	state := StatePayload{
+		Tick:     p.ticks,
+		You:      you,
+		Deadline: int(time.Duration(p.Rules.BotMoveDeadline) / time.Millisecond),
+		Width:    Boundary + 1,
+		Height:   Boundary + 1,
+		Portals:  p.portals,
+	}
+	for _, m := range p.sortedMovables() {
+		id := p.Movables[m]
+		w, ok := m.(*Worm)
+		if !ok || w.killed {
+			continue
+		}
+		state.Worms = append(state.Worms, WormState{
+			Id:        id,
+			Name:      w.Name,
+			Positions: append([]Position(nil), w.blocks...),
+			Direction: w.direction.String(),
+			Score:     w.Score,
+			Protected: w.Protected(),
+			Bot:       w.AI || w.Bot,
+		})
+	}
+	for _, f := range p.sortedFoods() {
+		kind, _ := p.Rules.Foods.Kind(f.Type)
+		state.Foods = append(state.Foods, FoodState{
+			Id:     f.Id,
+			X:      f.Position.X,
+			Y:      f.Position.Y,
+			Type:   f.Type,
+			Points: kind.Points,
+			Lethal: kind.Lethal,
+			Armed:  f.Armed(),
+		})
+	}
+	for _, pm := range p.pacmen {
+		state.PacMen = append(state.PacMen, pacManPacket(pm).Payload.(PacManPayload))
+	}
+	return Packet{Command: "STATE", Payload: state}
+}
+
+// sendBotStates hands every connected external bot its STATE frame for
+// this tick. A bot whose outbox is full just misses the frame; the
+// playfield never waits on one.
+func (p *Playfield) sendBotStates() {
+	for _, m := range p.sortedMovables() {
+		id := p.Movables[m]
+		w, ok := m.(*Worm)
+		if !ok || !w.Bot || !w.connected {
+			continue
+		}
+		if !trySend(w.Outbox, p.statePacket(id)) {
+			log.Print("Bot missed a STATE frame: ", w.Name)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package flow
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestValidBotKey(t *testing.T) {
+	defer func(old []string) { botKeys = old }(botKeys)
+	botKeys = []string{"alpha", "beta"}
+	if !validBotKey("beta") {
+		t.Errorf("Listed key should be accepted")
+	}
+	if validBotKey("gamma") || validBotKey("") {
+		t.Errorf("Unlisted keys should be refused")
+	}
+}
+
+func TestStatePacketDescribesField(t *testing.T) {
+	p := NewPlayfield()
+	bot := NewWorm()
+	bot.Bot = true
+	id := p.addMovable(bot)
+	p.Foods[1] = &Food{Id: 1, Position: Position{3, 4}, Type: Bomb}
+
+	state := p.statePacket(id).Payload.(StatePayload)
+	if state.You != id || len(state.Worms) != 1 || !state.Worms[0].Bot {
+		t.Errorf("Expected the bot itself in the frame, got %+v", state.Worms)
+	}
+	if len(state.Foods) != 1 || !state.Foods[0].Lethal {
+		t.Errorf("Expected the bomb flagged lethal, got %+v", state.Foods)
+	}
+}
+
+func TestSendBotStatesOnlyToConnectedBots(t *testing.T) {
+	p := NewPlayfield()
+	bot := NewWorm()
+	bot.Bot = true
+	bot.connected = true
+	p.addMovable(bot)
+	human := NewWorm()
+	human.connected = true
+	p.addMovable(human)
+
+	p.sendBotStates()
+
+	if len(bot.Outbox) != 1 || (<-bot.Outbox).Command != "STATE" {
+		t.Errorf("Connected bot should get a STATE frame")
+	}
+	if len(human.Outbox) != 0 {
+		t.Errorf("Humans should not get STATE frames")
+	}
+}
+
+func TestBotAloneDoesNotPopulateRoom(t *testing.T) {
+	p := NewPlayfield()
+	p.Rules.AdaptiveDifficulty = true
+	bot := NewWorm()
+	bot.Bot = true
+	bot.connected = true
+	bot.Score = 500
+	p.addMovable(bot)
+
+	p.reconcilePopulation()
+	p.reconcilePacMan()
+	p.adaptDifficulty()
+
+	if n := p.humanCount(); n != 0 {
+		t.Errorf("A bot API worm should not count as a human, got %d", n)
+	}
+	if len(p.Movables) != 1 || len(p.pacmen) != 0 {
+		t.Errorf("Expected no AI bots or Pac-Men for a bot alone, got %d movables and %d Pac-Men", len(p.Movables), len(p.pacmen))
+	}
+	if p.difficulty() == Hard {
+		t.Errorf("A bot's score should not set the adaptive difficulty")
+	}
+	if info := p.roomInfo(); info.Humans != 0 || info.Bots != 1 {
+		t.Errorf("Expected the bot listed as a bot, got %d humans and %d bots", info.Humans, info.Bots)
+	}
+}
+
+func TestBotConnectionGetsStateFrames(t *testing.T) {
+	once.Do(startServer)
+	defer func(old []string) { botKeys = old }(botKeys)
+	botKeys = []string{"secret"}
+
+	client, err := net.Dial("tcp", serverAddr)
+	if err != nil {
+		t.Fatal("dialing", err)
+	}
+	conn, err := websocket.NewClient(newConfig(t, "/worms"), client)
+	if err != nil {
+		t.Fatalf("WebSocket handshake error: %v", err)
+	}
+	defer conn.Close()
+
+	hello := Packet{Command: "HELLO", Payload: map[string]string{"Name": "Competitor", "BotKey": "secret"}}
+	if err := websocket.JSON.Send(conn, hello); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(Tick * 10 * time.Millisecond))
+	for {
+		var msg Packet
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			t.Fatalf("No STATE frame before the deadline: %v", err)
+		}
+		if msg.Command == "STATE" {
+			return
+		}
+	}
+}
+
+func TestBadBotKeyIsRefused(t *testing.T) {
+	once.Do(startServer)
+	defer func(old []string) { botKeys = old }(botKeys)
+	botKeys = []string{"secret"}
+
+	client, err := net.Dial("tcp", serverAddr)
+	if err != nil {
+		t.Fatal("dialing", err)
+	}
+	conn, err := websocket.NewClient(newConfig(t, "/worms"), client)
+	if err != nil {
+		t.Fatalf("WebSocket handshake error: %v", err)
+	}
+	defer conn.Close()
+
+	hello := Packet{Command: "HELLO", Payload: map[string]string{"BotKey": "guess"}}
+	if err := websocket.JSON.Send(conn, hello); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(Tick * 5 * time.Millisecond))
+	var msg Packet
+	if err := websocket.JSON.Receive(conn, &msg); err == nil {
+		t.Errorf("Expected the connection to be closed, got %+v", msg)
+	}
+}
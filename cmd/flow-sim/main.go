@@ -0,0 +1,113 @@
+// Command flow-sim plays headless matches and prints aggregate stats, so
+// balance changes can be measured instead of guessed:
+//
+//	go run ./cmd/flow-sim -bots 3 -ticks 5000 -runs 20
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/balboah/flow"
+)
+
+var (
+	seed          = flag.Uint64("seed", 1, "Seed of the first run; run i uses seed+i")
+	runs          = flag.Int("runs", 1, "Number of matches to play and aggregate")
+	ticks         = flag.Int("ticks", 5000, "Ticks per match")
+	bots          = flag.Int("bots", 3, "AI bots per match")
+	humans        = flag.Int("humans", 1, "Simulated humans per match (1 to 16)")
+	humanStrategy = flag.String("human-strategy", flow.DefaultStrategy, "Strategy steering the simulated humans")
+	foods         = flag.String("foods", "", "JSON food catalogue to use instead of the built-in one")
+	difficulty    = flag.String("difficulty", "normal", "Bot difficulty: easy, normal, hard or nightmare")
+	personas      = flag.String("personas", "", "JSON personas (see cmd/flow-tune) to hand out to bots")
+	verbose       = flag.Bool("v", false, "Keep the playfield's log output")
+)
+
+func main() {
+	flag.Parse()
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	rules := flow.DefaultRules()
+	if *foods != "" {
+		catalogue, err := flow.LoadFoodCatalogue(*foods)
+		if err != nil {
+			fatal("Loading foods: %v", err)
+		}
+		rules.Foods = catalogue
+	}
+	level, err := flow.ParseDifficulty(*difficulty)
+	if err != nil {
+		fatal("%v", err)
+	}
+	rules.Difficulty = level
+	if *personas != "" {
+		ps, err := flow.LoadPersonas(*personas)
+		if err != nil {
+			fatal("Loading personas: %v", err)
+		}
+		rules.Personas = ps
+	}
+
+	cfg := flow.SimConfig{
+		Ticks:         *ticks,
+		Bots:          *bots,
+		Humans:        *humans,
+		HumanStrategy: *humanStrategy,
+		Rules:         rules,
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("%v", err)
+	}
+	var total flow.SimStats
+	for i := 0; i < *runs; i++ {
+		cfg.Seed = *seed + uint64(i)
+		stats, err := flow.Simulate(cfg)
+		if err != nil {
+			fatal("%v", err)
+		}
+		total.Add(stats)
+	}
+	report(os.Stdout, total)
+}
+
+func report(out io.Writer, s flow.SimStats) {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ticks\t%d\n", s.Ticks)
+	fmt.Fprintf(tw, "lives\t%d\n", s.Lives())
+	fmt.Fprintf(tw, "average lifespan\t%.1f ticks\n", s.AverageLifespan())
+	fmt.Fprintf(tw, "scores p10/p50/p90/max\t%d / %d / %d / %d\n",
+		s.ScorePercentile(0.1), s.ScorePercentile(0.5), s.ScorePercentile(0.9), s.ScorePercentile(1))
+	fmt.Fprintf(tw, "pac-man bites\t%d (%.2f per 1000 ticks)\n", s.PacManBites, s.BiteRate())
+	fmt.Fprintln(tw, "deaths by cause")
+	causes := make([]flow.DeathCause, 0, len(s.Deaths))
+	for c := range s.Deaths {
+		causes = append(causes, c)
+	}
+	sort.Slice(causes, func(i, j int) bool {
+		if s.Deaths[causes[i]] != s.Deaths[causes[j]] {
+			return s.Deaths[causes[i]] > s.Deaths[causes[j]]
+		}
+		return causes[i] < causes[j]
+	})
+	for _, c := range causes {
+		share := 0.0
+		if s.Lives() > 0 {
+			share = 100 * float64(s.Deaths[c]) / float64(s.Lives())
+		}
+		fmt.Fprintf(tw, "  %s\t%d (%.0f%%)\n", c, s.Deaths[c], share)
+	}
+	tw.Flush()
+}
+
+func fatal(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "flow-sim: "+format+"\n", args...)
+	os.Exit(1)
+}
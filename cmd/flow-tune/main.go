@@ -0,0 +1,72 @@
+// Command flow-tune evolves bot personas with headless matches and writes
+// the best ones to a file the server loads with -personas:
+//
+//	go run ./cmd/flow-tune -generations 20 -out personas.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/balboah/flow"
+)
+
+var (
+	seed        = flag.Uint64("seed", 1, "Seed for the whole run")
+	population  = flag.Int("population", 12, "Genomes per generation")
+	generations = flag.Int("generations", 10, "Generations to evolve")
+	matches     = flag.Int("matches", 8, "Matches played per generation")
+	ticks       = flag.Int("ticks", 2000, "Ticks per match")
+	bots        = flag.Int("bots", 3, "Genomes seated in each match")
+	survival    = flag.Float64("survival", 5, "Fitness worth of 100 ticks alive, next to one per point scored")
+	keep        = flag.Int("keep", 6, "Best genomes to write out")
+	out         = flag.String("out", "personas.json", "Where to write the personas")
+	foods       = flag.String("foods", "", "JSON food catalogue to use instead of the built-in one")
+	verbose     = flag.Bool("v", false, "Keep the playfield's log output")
+)
+
+func main() {
+	flag.Parse()
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	rules := flow.DefaultRules()
+	if *foods != "" {
+		catalogue, err := flow.LoadFoodCatalogue(*foods)
+		if err != nil {
+			fatal("Loading foods: %v", err)
+		}
+		rules.Foods = catalogue
+	}
+
+	personas, err := flow.Evolve(flow.TuneConfig{
+		Seed:         *seed,
+		Population:   *population,
+		Generations:  *generations,
+		Matches:      *matches,
+		Ticks:        *ticks,
+		BotsPerMatch: *bots,
+		Survival:     *survival,
+		Keep:         *keep,
+		Rules:        rules,
+		Progress: func(gen int, best flow.AIPersonality, fitness float64) {
+			fmt.Printf("generation %d: best %s (fitness %.1f)\n", gen+1, best.Name, fitness)
+		},
+	})
+	if err != nil {
+		fatal("%v", err)
+	}
+	if err := flow.SavePersonas(*out, personas); err != nil {
+		fatal("Writing personas: %v", err)
+	}
+	fmt.Printf("wrote %d personas to %s\n", len(personas), *out)
+}
+
+func fatal(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "flow-tune: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,162 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config is everything a flow server is set up with. Settings are layered:
+// DefaultConfig, then a JSON config file (LoadConfig), then environment
+// variables (ApplyEnv), then whatever command-line flags the server
+// takes; Validate checks the result before the server starts. Secrets
+// (FLOW_BOT_KEYS, FLOW_ADMIN_KEYS) stay in the environment and out of
+// Config, so a printed config is safe to commit.
+type Config struct {
+	Server ServerConfig
+	Limits Limits
+	// Rules are what every room starts with.
+	Rules Rules
+}
+
+// ServerConfig is where the server listens and keeps its files. Empty
+// paths keep the leaderboard and profiles in memory and turn replays and
+// the event log off. The leaderboard and profiles are in memory by default,
+// since the server may run where it can't write, such as the container's
+// WORKDIR.
+type ServerConfig struct {
+	Port        int
+	WWW         string // web root
+	Leaderboard string
+	Profiles    string
+	Replays     string // directory
+	Events      string
+
+	// ReplayMaxTicks is how long each replay file runs and ReplayKeep how
+	// many files the replay directory keeps. ProfileFlushInterval is how
+	// often changed profiles are written.
+	ReplayMaxTicks       int
+	ReplayKeep           int
+	ProfileFlushInterval Duration
+}
+
+// Duration is a time.Duration written as a string such as "5s" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Limits caps inbound connections. An empty AllowedOrigins accepts any
+// origin, which is meant for local use only.
+type Limits struct {
+	MaxConnsPerIP  int
+	MaxConns       int
+	AllowedOrigins []string
+}
+
+// DefaultConfig is what the server runs with when nothing is set.
+func DefaultConfig() Config {
+	return Config{
+		Server: ServerConfig{
+			Port: 5000,
+			WWW:  "html",
+
+			ReplayMaxTicks:       ReplayMaxTicks,
+			ReplayKeep:           ReplayKeep,
+			ProfileFlushInterval: Duration(ProfileFlushInterval),
+		},
+		Limits: Limits{
+			MaxConnsPerIP: defaultMaxConnsPerIP,
+			MaxConns:      defaultMaxConns,
+		},
+		Rules: DefaultRules(),
+	}
+}
+
+// LoadConfig reads a JSON config file over DefaultConfig: settings the
+// file leaves out keep their defaults, lists it sets (such as
+// Rules.Foods) replace the default ones whole. Unknown settings are an
+// error, so a typo can't go unnoticed.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ApplyEnv overrides cfg with the environment variables the server has
+// always read: PORT, FLOW_MAX_CONNS_PER_IP, FLOW_MAX_CONNS and
+// FLOW_ALLOWED_ORIGINS (comma-separated). lookup is os.LookupEnv outside
+// tests. A set but malformed number is an error.
+func (cfg *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"PORT", &cfg.Server.Port},
+		{"FLOW_MAX_CONNS_PER_IP", &cfg.Limits.MaxConnsPerIP},
+		{"FLOW_MAX_CONNS", &cfg.Limits.MaxConns},
+	}
+	for _, v := range ints {
+		raw, ok := lookup(v.name)
+		if !ok || raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number", v.name, raw)
+		}
+		*v.dst = n
+	}
+	if raw, ok := lookup("FLOW_ALLOWED_ORIGINS"); ok {
+		cfg.Limits.AllowedOrigins = SplitList(raw)
+	}
+	return nil
+}
+
+// Validate reports the first setting the server can't start with.
+func (cfg Config) Validate() error {
+	if cfg.Server.Port < 1 || cfg.Server.Port > 65535 {
+		return fmt.Errorf("Server.Port %d is not a port", cfg.Server.Port)
+	}
+	if cfg.Server.WWW == "" {
+		return errors.New("Server.WWW must name the web root")
+	}
+	if cfg.Server.ReplayMaxTicks < 1 || cfg.Server.ReplayKeep < 1 {
+		return errors.New("Server.ReplayMaxTicks and Server.ReplayKeep must be positive")
+	}
+	if cfg.Server.ProfileFlushInterval <= 0 {
+		return errors.New("Server.ProfileFlushInterval must be positive")
+	}
+	if cfg.Limits.MaxConnsPerIP < 1 || cfg.Limits.MaxConns < 1 {
+		return errors.New("Limits.MaxConnsPerIP and Limits.MaxConns must be positive")
+	}
+	if cfg.Limits.MaxConnsPerIP > cfg.Limits.MaxConns {
+		return errors.New("Limits.MaxConnsPerIP must not exceed Limits.MaxConns")
+	}
+	if err := cfg.Rules.Validate(); err != nil {
+		return fmt.Errorf("Rules: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package flow
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrintedConfigLoadsBack(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Server.Port = 8080
+	cfg.Limits.AllowedOrigins = []string{"https://flow.example"}
+	cfg.Rules.Bots = 2
+	cfg.Rules.DisconnectTTL = Duration(90 * time.Second)
+	raw, _ := json.MarshalIndent(cfg, "", "  ")
+	path := filepath.Join(t.TempDir(), "flow.json")
+	os.WriteFile(path, raw, 0o644)
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("Expected the printed config back, got %+v", loaded)
+	}
+}
+
+func TestConfigFileKeepsDefaultsAndRejectsTypos(t *testing.T) {
+	dir := t.TempDir()
+	partial := filepath.Join(dir, "partial.json")
+	os.WriteFile(partial, []byte(`{"Rules": {"KillPoints": 50}}`), 0o644)
+	cfg, err := LoadConfig(partial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Rules.KillPoints != 50 || cfg.Server.Port != 5000 || len(cfg.Rules.Foods) == 0 {
+		t.Errorf("Expected KillPoints set over the defaults, got %+v", cfg)
+	}
+
+	typo := filepath.Join(dir, "typo.json")
+	os.WriteFile(typo, []byte(`{"Rules": {"KillPionts": 50}}`), 0o644)
+	if _, err := LoadConfig(typo); err == nil {
+		t.Error("Expected an unknown setting refused")
+	}
+}
+
+func TestEnvOverridesConfig(t *testing.T) {
+	env := map[string]string{"PORT": "9000", "FLOW_ALLOWED_ORIGINS": "a, b"}
+	lookup := func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+	cfg := DefaultConfig()
+	cfg.Limits.MaxConns = 10
+	if err := cfg.ApplyEnv(lookup); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 9000 || cfg.Limits.MaxConns != 10 || !reflect.DeepEqual(cfg.Limits.AllowedOrigins, []string{"a", "b"}) {
+		t.Errorf("Unexpected config after env: %+v", cfg)
+	}
+	env["FLOW_MAX_CONNS"] = "lots"
+	if err := cfg.ApplyEnv(lookup); err == nil {
+		t.Error("Expected a malformed number refused")
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	for name, mutate := range map[string]func(*Config){
+		"port":       func(c *Config) { c.Server.Port = 0 },
+		"per-ip":     func(c *Config) { c.Limits.MaxConnsPerIP = c.Limits.MaxConns + 1 },
+		"difficulty": func(c *Config) { c.Rules.Difficulty = "impossible" },
+		"bots":       func(c *Config) { c.Rules.Bots = MaxBots + 1 },
+		"foods":      func(c *Config) { c.Rules.Foods = nil },
+		"kill":       func(c *Config) { c.Rules.KillPoints = -1 },
+		"tick":       func(c *Config) { c.Rules.Tick = MaxTick + 1 },
+		"deadline":   func(c *Config) { c.Rules.BotMoveDeadline = Duration(time.Second) },
+		"combo":      func(c *Config) { c.Rules.Combo.MaxMultiplier = 0 },
+		"pacmen":     func(c *Config) { c.Rules.HumansPerPacMan = 0 },
+		"replays":    func(c *Config) { c.Server.ReplayKeep = 0 },
+		"flush":      func(c *Config) { c.Server.ProfileFlushInterval = 0 },
+	} {
+		cfg := DefaultConfig()
+		mutate(&cfg)
+		if cfg.Validate() == nil {
+			t.Errorf("%s: expected the config refused", name)
+		}
+	}
+}
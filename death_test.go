@@ -301,3 +301,204 @@ func TestResetReturnsToSpawn(t *testing.T) {
 		t.Errorf("direction should reset")
 	}
 }
+
+func TestDeathDropsRemains(t *testing.T) {
+	p := NewPlayfield()
+	a := NewWorm()
+	a.blocks = []Position{{10, 10}, {9, 10}, {8, 10}, {7, 10}, {6, 10}}
+	a.direction = Right
+	p.addMovable(a)
+
+	b := NewWorm()
+	b.blocks = []Position{{11, 10}, {11, 11}, {11, 12}}
+	b.direction = Up
+	p.addMovable(b)
+
+	p.tick()
+
+	if !a.killed {
+		t.Fatalf("A should die crashing into B")
+	}
+	remains := map[Position]bool{}
+	for _, f := range p.Foods {
+		if f.Type == Remains {
+			remains[f.Position] = true
+		}
+	}
+	// A's head sits on B's body, so the first strided cell is skipped.
+	want := []Position{{9, 10}, {7, 10}}
+	if len(remains) != len(want) {
+		t.Errorf("Expected %d remains, got %v", len(want), remains)
+	}
+	for _, c := range want {
+		if !remains[c] {
+			t.Errorf("Expected remains at %v", c)
+		}
+	}
+}
+
+func TestCrashCreditsKiller(t *testing.T) {
+	p := NewPlayfield()
+	a := NewWorm()
+	a.Name = "A"
+	a.blocks = []Position{{10, 10}, {9, 10}, {8, 10}}
+	a.direction = Right
+	p.addMovable(a)
+
+	b := NewWorm()
+	b.Name = "B"
+	b.blocks = []Position{{11, 10}, {11, 11}, {11, 12}}
+	b.direction = Up
+	bId := p.addMovable(b)
+
+	p.tick()
+
+	if a.DeathCause() != CauseCrash || a.killer != b {
+		t.Fatalf("A should be a crash credited to B, got cause=%q killer=%v", a.DeathCause(), a.killer)
+	}
+	if b.Score != p.Rules.KillPoints {
+		t.Errorf("B should be credited %d kill points, got %d", p.Rules.KillPoints, b.Score)
+	}
+
+	var gameOver *GameOverPayload
+	var feed *KillFeedPayload
+	for len(p.Broadcast) > 0 {
+		pkt := <-p.Broadcast
+		switch pl := pkt.Payload.(type) {
+		case GameOverPayload:
+			gameOver = &pl
+		case KillFeedPayload:
+			feed = &pl
+		}
+	}
+	if gameOver == nil || gameOver.KillerId != bId || gameOver.Cause != CauseCrash {
+		t.Errorf("GAMEOVER should name B as killer with a crash cause, got %+v", gameOver)
+	}
+	if feed == nil || feed.KillerName != "B" || feed.VictimName != "A" {
+		t.Errorf("KILLFEED should read B killed A, got %+v", feed)
+	}
+}
+
+func TestHeadOnCreditsNoKiller(t *testing.T) {
+	p := NewPlayfield()
+	p.Profiles, _ = OpenProfiles("", ProfileFlushInterval)
+	a := NewWorm()
+	a.Token = "a"
+	a.blocks = []Position{{10, 10}, {9, 10}, {8, 10}}
+	a.direction = Right
+	p.addMovable(a)
+
+	b := NewWorm()
+	b.Token = "b"
+	b.blocks = []Position{{12, 10}, {13, 10}, {14, 10}}
+	b.direction = Left
+	p.addMovable(b)
+
+	p.tick()
+
+	if !a.killed || !b.killed || a.killer != nil || b.killer != nil {
+		t.Fatalf("Expected both to die with no killer, killers %v and %v", a.killer, b.killer)
+	}
+	if a.Score != 0 || b.Score != 0 {
+		t.Errorf("Expected no kill points: a=%d b=%d", a.Score, b.Score)
+	}
+	for _, token := range []string{"a", "b"} {
+		if prof, _ := p.Profiles.Get(PlayerId(token)); prof.Kills != 0 {
+			t.Errorf("Expected no kill in %s's profile, got %d", token, prof.Kills)
+		}
+	}
+	for len(p.Broadcast) > 0 {
+		if feed, ok := (<-p.Broadcast).Payload.(KillFeedPayload); ok && feed.KillerId != 0 {
+			t.Errorf("Expected KILLFEED without a killer, got %+v", feed)
+		}
+	}
+}
+
+func TestSpawnProtectionIgnoresBodyContact(t *testing.T) {
+	p := NewPlayfield()
+	a := NewWorm()
+	a.blocks = []Position{{10, 10}, {9, 10}, {8, 10}}
+	a.direction = Right
+	a.protection = 2
+	p.addMovable(a)
+
+	b := NewWorm()
+	b.blocks = []Position{{11, 10}, {11, 11}, {11, 12}}
+	b.direction = Up
+	p.addMovable(b)
+
+	p.tick()
+
+	if a.killed || b.killed {
+		t.Errorf("Protected worm should pass through: a.killed=%v b.killed=%v", a.killed, b.killed)
+	}
+	if a.protection != 1 {
+		t.Errorf("Protection should count down once per tick, got %d", a.protection)
+	}
+}
+
+func TestRespawnWaitsForCooldown(t *testing.T) {
+	p := NewPlayfield()
+	w := NewWorm()
+	id := p.addMovable(w)
+	w.die(CauseSelf, "Ate yourself", nil)
+	p.announceDeath(w)
+
+	if w.respawnCooldown != p.Rules.RespawnCooldown {
+		t.Fatalf("Death should start the respawn cooldown, got %d", w.respawnCooldown)
+	}
+	// What the Respawn handler does for an early request.
+	w.respawnPending = true
+
+	for i := 0; i < p.Rules.RespawnCooldown-1; i++ {
+		p.tick()
+		if !w.killed {
+			t.Fatalf("Respawned after %d ticks, before the cooldown ran out", i+1)
+		}
+	}
+	p.tick()
+	if w.killed {
+		t.Fatalf("Pending respawn should apply once the cooldown runs out")
+	}
+	if !w.Protected() {
+		t.Errorf("Respawned worm should be spawn-protected")
+	}
+
+	var welcomed bool
+	for len(w.Outbox) > 0 {
+		if pkt := <-w.Outbox; pkt.Command == "WELCOME" && pkt.Payload.(WelcomePayload).Id == id {
+			welcomed = true
+		}
+	}
+	if !welcomed {
+		t.Errorf("Respawn should send a fresh WELCOME")
+	}
+}
+
+func TestRespawnIgnoresLivingWorm(t *testing.T) {
+	p := NewPlayfield()
+	w := addWormAt(p, Position{X: 10, Y: 10})
+	w.protection = p.Rules.SpawnProtection
+	w.respawnCooldown = p.Rules.RespawnCooldown
+
+	p.requestRespawn(w)
+	if w.respawnPending {
+		t.Fatal("RESPAWN from a living worm should not be queued")
+	}
+	// Even a stale pending flag must not move a worm that is alive.
+	w.respawnPending = true
+	for i := 1; i <= p.Rules.RespawnCooldown+1; i++ {
+		p.tick()
+		if want := (Position{X: 10 + i, Y: 10}); w.Head() != want || w.killed {
+			t.Fatalf("Living worm was reset after %d ticks: head %v, want %v", i, w.Head(), want)
+		}
+	}
+
+	p.Rules.RespawnCooldown = 0
+	w.respawnCooldown = 0
+	head := w.Head()
+	p.requestRespawn(w)
+	if w.Head() != head {
+		t.Errorf("RESPAWN without a cooldown moved a living worm from %v to %v", head, w.Head())
+	}
+}
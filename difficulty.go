@@ -0,0 +1,146 @@
+package flow
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+)
+
+// Difficulty names a preset for how good a room's bots are.
+type Difficulty string
+
+const (
+	Easy      Difficulty = "easy"
+	Normal    Difficulty = "normal"
+	Hard      Difficulty = "hard"
+	Nightmare Difficulty = "nightmare"
+)
+
+// Range is an inclusive span a personality trait is drawn from.
+type Range struct {
+	Min, Max float64
+}
+
+func (r Range) draw(rng *rand.Rand) float64 {
+	return r.Min + rng.Float64()*(r.Max-r.Min)
+}
+
+// DifficultyPreset holds the trait ranges newPersonality draws from at one
+// difficulty, and whether its bots look ahead for dead ends.
+type DifficultyPreset struct {
+	FoodPull       Range
+	Inertia        Range
+	CenterPull     Range
+	HesitationRate Range
+	MistakeRate    Range
+	PacManFear     Range
+	Lookahead      bool
+}
+
+// difficultyPresets: normal is the original bot. Easy bots dither, blunder
+// and barely notice Pac-Man; hard bots rarely slip and check for dead ends;
+// nightmare bots never slip at all.
+var difficultyPresets = map[Difficulty]DifficultyPreset{
+	Easy: {
+		FoodPull:       Range{0.5, 1.5},
+		Inertia:        Range{0.5, 3.0},
+		CenterPull:     Range{0.0, 0.5},
+		HesitationRate: Range{0.10, 0.20},
+		MistakeRate:    Range{0.08, 0.15},
+		PacManFear:     Range{0.3, 0.7},
+	},
+	Normal: {
+		FoodPull:       Range{0.8, 2.8},
+		Inertia:        Range{0.5, 3.0},
+		CenterPull:     Range{0.0, 0.5},
+		HesitationRate: Range{0.03, 0.10},
+		MistakeRate:    Range{0.02, 0.07},
+		PacManFear:     Range{0.6, 1.2},
+	},
+	Hard: {
+		FoodPull:       Range{1.5, 3.0},
+		Inertia:        Range{0.5, 2.0},
+		CenterPull:     Range{0.0, 0.3},
+		HesitationRate: Range{0.01, 0.04},
+		MistakeRate:    Range{0.005, 0.02},
+		PacManFear:     Range{0.9, 1.4},
+		Lookahead:      true,
+	},
+	Nightmare: {
+		FoodPull:   Range{2.5, 3.5},
+		Inertia:    Range{0.3, 1.5},
+		CenterPull: Range{0.0, 0.2},
+		PacManFear: Range{1.2, 1.8},
+		Lookahead:  true,
+	},
+}
+
+// Preset returns d's trait ranges; unknown difficulties get Normal's.
+func (d Difficulty) Preset() DifficultyPreset {
+	if preset, ok := difficultyPresets[d]; ok {
+		return preset
+	}
+	return difficultyPresets[Normal]
+}
+
+// ParseDifficulty checks that s names a preset.
+func ParseDifficulty(s string) (Difficulty, error) {
+	d := Difficulty(s)
+	if _, ok := difficultyPresets[d]; !ok {
+		return "", fmt.Errorf("unknown difficulty %q (want easy, normal, hard or nightmare)", s)
+	}
+	return d, nil
+}
+
+// adaptiveLevels maps the best human score to a difficulty when
+// Rules.AdaptiveDifficulty is on: the highest level whose Score the leader
+// has reached applies.
+var adaptiveLevels = []struct {
+	Score int
+	Level Difficulty
+}{
+	{0, Easy},
+	{150, Normal},
+	{400, Hard},
+	{1000, Nightmare},
+}
+
+// difficulty is the level new and retuned bots are drawn at right now.
+func (p *Playfield) difficulty() Difficulty {
+	if p.adaptedDifficulty != "" {
+		return p.adaptedDifficulty
+	}
+	return p.Rules.Difficulty
+}
+
+// adaptDifficulty follows the best human score when Rules.AdaptiveDifficulty
+// is on. When the level changes every bot is retuned in place from its
+// seed, so it keeps its name but plays at the new level straight away.
+// Persona bots (Rules.Personas) keep their tuning.
+func (p *Playfield) adaptDifficulty() {
+	if !p.Rules.AdaptiveDifficulty {
+		return
+	}
+	best := 0
+	for m := range p.Movables {
+		if w, ok := m.(*Worm); ok && isHuman(w) && w.Score > best {
+			best = w.Score
+		}
+	}
+	level := adaptiveLevels[0].Level
+	for _, l := range adaptiveLevels {
+		if best >= l.Score {
+			level = l.Level
+		}
+	}
+	if level == p.difficulty() {
+		return
+	}
+	log.Printf("Bot difficulty %s -> %s (best human score %d)", p.difficulty(), level, best)
+	p.adaptedDifficulty = level
+	for m := range p.Movables {
+		if w, ok := m.(*Worm); ok && w.AI && !p.Rules.Personas.has(w.Name) {
+			w.personality = personalityFor(w.personality.Seed, level)
+		}
+	}
+}
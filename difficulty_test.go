@@ -0,0 +1,67 @@
+package flow
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestPersonalityDrawnWithinPreset(t *testing.T) {
+	for _, d := range []Difficulty{Easy, Normal, Hard, Nightmare} {
+		preset := d.Preset()
+		rng := rand.New(rand.NewPCG(1, 2))
+		for i := 0; i < 20; i++ {
+			pers := newPersonality(rng, d)
+			if pers.MistakeRate < preset.MistakeRate.Min || pers.MistakeRate > preset.MistakeRate.Max {
+				t.Errorf("%s: MistakeRate %v outside %v", d, pers.MistakeRate, preset.MistakeRate)
+			}
+			if pers.Lookahead != preset.Lookahead {
+				t.Errorf("%s: Lookahead should be %v", d, preset.Lookahead)
+			}
+		}
+	}
+}
+
+func TestRetuningKeepsBotIdentity(t *testing.T) {
+	easy := personalityFor(42, Easy)
+	hard := personalityFor(42, Hard)
+	if easy.Name != hard.Name {
+		t.Errorf("Same seed should keep the same name, got %s and %s", easy.Name, hard.Name)
+	}
+	if hard.MistakeRate >= easy.MistakeRate {
+		t.Errorf("Hard bots should blunder less than easy ones")
+	}
+}
+
+func TestParseDifficulty(t *testing.T) {
+	if d, err := ParseDifficulty("nightmare"); err != nil || d != Nightmare {
+		t.Errorf("Expected nightmare, got %q, %v", d, err)
+	}
+	if _, err := ParseDifficulty("impossible"); err == nil {
+		t.Errorf("Unknown difficulties should be rejected")
+	}
+}
+
+func TestAdaptiveDifficultyFollowsBestHuman(t *testing.T) {
+	p := NewPlayfield()
+	p.Rules.AdaptiveDifficulty = true
+	human := NewWorm()
+	p.addMovable(human)
+	bot := NewWorm()
+	bot.AI = true
+	bot.personality = personalityFor(7, Easy)
+	p.addMovable(bot)
+
+	p.adaptDifficulty()
+	if p.difficulty() != Easy {
+		t.Fatalf("A fresh room should start easy, got %s", p.difficulty())
+	}
+
+	human.Score = 500
+	p.adaptDifficulty()
+	if p.difficulty() != Hard {
+		t.Fatalf("Expected hard at 500 points, got %s", p.difficulty())
+	}
+	if !bot.personality.Lookahead || bot.personality.Name != personalityFor(7, Easy).Name {
+		t.Errorf("Existing bots should be retuned in place")
+	}
+}
@@ -0,0 +1,250 @@
+package flow
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// EventKind says what an Event is about.
+type EventKind string
+
+const (
+	EventJoin    EventKind = "join"    // a worm entered the field
+	EventLeave   EventKind = "leave"   // a worm was taken off it
+	EventEat     EventKind = "eat"     // a worm ate a food or a frightened Pac-Man
+	EventDeath   EventKind = "death"   // a worm died
+	EventBite    EventKind = "bite"    // a worm lost its tail to a Pac-Man or a bomb
+	EventRespawn EventKind = "respawn" // a dead worm came back
+	EventRename  EventKind = "rename"  // a worm changed its name
+	// EventRoundEnd marks the last human going offline. A round is the
+	// stretch a room has someone playing in it; bots don't run outside one.
+	EventRoundEnd EventKind = "round_end"
+)
+
+// Event is one thing that happened on a playfield, for analytics and
+// moderation. Which fields are set depends on Kind; the rest are left out
+// of the JSON.
+type Event struct {
+	Kind EventKind
+	At   time.Time
+	Room string
+	Tick int
+
+	WormId Id     `json:",omitempty"`
+	Name   string `json:",omitempty"`
+	// Player is the public profile id (see PlayerId) of a human's worm.
+	Player string `json:",omitempty"`
+	Bot    bool   `json:",omitempty"`
+
+	Score  int `json:",omitempty"` // after the event
+	Length int `json:",omitempty"`
+
+	Food     FoodType   `json:",omitempty"` // eat
+	Points   int        `json:",omitempty"` // eat: points credited
+	PacManId Id         `json:",omitempty"` // eat, bite
+	Lost     int        `json:",omitempty"` // bite: segments lost
+	Cause    DeathCause `json:",omitempty"` // death
+	Reason   string     `json:",omitempty"` // death
+	KillerId Id         `json:",omitempty"` // death
+	Killer   string     `json:",omitempty"` // death: the killer's name
+	OldName  string     `json:",omitempty"` // rename
+	Ticks    int        `json:",omitempty"` // round_end: how long the round ran
+}
+
+// EventSink receives a playfield's events. Emit is called from the
+// playfield goroutine, so it must not block on anything slow.
+type EventSink interface {
+	Emit(Event)
+}
+
+// EventSinks sends every event to each of its sinks in turn.
+type EventSinks []EventSink
+
+func (ss EventSinks) Emit(e Event) {
+	for _, s := range ss {
+		s.Emit(e)
+	}
+}
+
+// EventLogBuffer is how many events an EventLog holds for its writer
+// before it starts dropping them.
+const EventLogBuffer = 1024
+
+// EventLog writes events to a file, one JSON object per line. Emit only
+// queues the event; a writer goroutine does the disk work, so a slow disk
+// costs events rather than ticks.
+type EventLog struct {
+	mu      sync.Mutex
+	queue   chan Event
+	closed  bool
+	dropped int
+	done    chan struct{}
+	file    io.WriteCloser
+	err     error // from closing file
+}
+
+// OpenEventLog appends to the log at path, creating it if needed.
+func OpenEventLog(path string) (*EventLog, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return newEventLog(f, EventLogBuffer), nil
+}
+
+func newEventLog(file io.WriteCloser, buffer int) *EventLog {
+	l := &EventLog{queue: make(chan Event, buffer), done: make(chan struct{}), file: file}
+	go l.write()
+	return l
+}
+
+// write encodes queued events until Close, flushing whenever it has
+// caught up. Write errors are logged, not returned: a full disk shouldn't
+// stop the game.
+func (l *EventLog) write() {
+	defer close(l.done)
+	w := bufio.NewWriter(l.file)
+	enc := json.NewEncoder(w)
+	for e := range l.queue {
+		if err := enc.Encode(e); err != nil {
+			log.Printf("Event log write: %v", err)
+		}
+		if len(l.queue) == 0 {
+			if err := w.Flush(); err != nil {
+				log.Printf("Event log write: %v", err)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Printf("Event log write: %v", err)
+	}
+	l.err = l.file.Close()
+}
+
+// Emit queues e for the writer, or drops it if the writer is
+// EventLogBuffer events behind.
+func (l *EventLog) Emit(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	if !trySend(l.queue, e) {
+		l.dropped++
+	}
+}
+
+// Dropped is how many events were skipped because the writer was behind.
+func (l *EventLog) Dropped() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// Close writes out what's queued and closes the file; events emitted
+// afterwards are dropped.
+func (l *EventLog) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.queue)
+	l.mu.Unlock()
+	<-l.done
+	return l.err
+}
+
+// EventBus hands events to in-process subscribers. Each subscriber has its
+// own buffer; one that falls behind misses events rather than holding up
+// the game.
+type EventBus struct {
+	mu      sync.Mutex
+	subs    map[chan Event]struct{}
+	dropped int
+}
+
+// NewEventBus returns a bus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: map[chan Event]struct{}{}}
+}
+
+// Subscribe returns a channel that receives every event emitted from now
+// on, buffering up to buffer of them, and a function that unsubscribes
+// and closes the channel.
+func (b *EventBus) Subscribe(buffer int) (<-chan Event, func()) {
+	c := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subs[c] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return c, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, c)
+			b.mu.Unlock()
+			close(c)
+		})
+	}
+}
+
+func (b *EventBus) Emit(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.subs {
+		if !trySend(c, e) {
+			b.dropped++
+		}
+	}
+}
+
+// Dropped is how many deliveries were skipped because a subscriber's
+// buffer was full.
+func (b *EventBus) Dropped() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// events is what lobby playfields emit to; see SetEvents.
+var events EventSink
+
+// SetEvents makes playfields created from now on emit their events to s.
+func SetEvents(s EventSink) {
+	lobby.mu.Lock()
+	events = s
+	lobby.mu.Unlock()
+}
+
+// emit stamps e with where and when it happened and hands it to the sink.
+func (p *Playfield) emit(e Event) {
+	if p.Events == nil {
+		return
+	}
+	e.At = time.Now().UTC()
+	e.Room = p.Room
+	e.Tick = p.ticks
+	p.Events.Emit(e)
+}
+
+// wormEvent starts an event about w.
+func (p *Playfield) wormEvent(kind EventKind, w *Worm) Event {
+	e := Event{
+		Kind:   kind,
+		WormId: p.Movables[w],
+		Name:   w.Name,
+		Bot:    w.AI || w.Bot,
+		Score:  w.Score,
+		Length: len(w.blocks),
+	}
+	if !w.AI {
+		e.Player = PlayerId(w.Token)
+	}
+	return e
+}
@@ -0,0 +1,163 @@
+package flow
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// collect drains everything c holds right now.
+func collect(c <-chan Event) []Event {
+	var out []Event
+	for {
+		select {
+		case e := <-c:
+			out = append(out, e)
+		default:
+			return out
+		}
+	}
+}
+
+func TestPlayfieldEmitsLifeEvents(t *testing.T) {
+	bus := NewEventBus()
+	c, cancel := bus.Subscribe(16)
+	defer cancel()
+	p := NewPlayfield()
+	p.Room = "1"
+	p.Events = bus
+
+	w := NewWorm()
+	w.Token = "tok"
+	w.Name = "ada"
+	w.connected = true
+	id := p.addMovable(w)
+	p.announceJoin(w, id)
+	p.LastFoodId++
+	apple := Food{Id: p.LastFoodId, Position: w.Head(), Type: Apple}
+	p.Foods[apple.Id] = &apple
+	p.resolveFoodCollisions()
+	w.die(CauseCrash, "Crashed", nil)
+	p.announceDeath(w)
+	p.respawn(w, id)
+	p.removeMovable(w)
+
+	got := collect(c)
+	want := []EventKind{EventJoin, EventEat, EventDeath, EventRespawn, EventLeave}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %+v", want, got)
+	}
+	for i, e := range got {
+		if e.Kind != want[i] || e.WormId != id || e.Room != "1" || e.Player != PlayerId("tok") {
+			t.Errorf("Event %d: expected %s for worm %d, got %+v", i, want[i], id, e)
+		}
+	}
+	if got[1].Food != Apple || got[1].Points != p.Rules.Foods.Points(Apple) {
+		t.Errorf("Expected the eat event to name the apple and its points, got %+v", got[1])
+	}
+	if got[2].Cause != CauseCrash || got[2].Reason != "Crashed" {
+		t.Errorf("Expected the death's cause and reason, got %+v", got[2])
+	}
+}
+
+func TestRoundEndsWhenLastHumanLeaves(t *testing.T) {
+	bus := NewEventBus()
+	c, cancel := bus.Subscribe(16)
+	defer cancel()
+	p := NewPlayfield()
+	p.Events = bus
+	w := addWormAt(p, Position{X: 10, Y: 10})
+	w.connected = true
+	p.tick()
+	p.tick()
+	w.connected = false
+	p.tick()
+
+	for _, e := range collect(c) {
+		if e.Kind == EventRoundEnd {
+			if e.Ticks != 2 {
+				t.Errorf("Expected a two-tick round, got %d", e.Ticks)
+			}
+			return
+		}
+	}
+	t.Error("Expected a round_end event")
+}
+
+func TestEventBusDropsForSlowSubscribers(t *testing.T) {
+	bus := NewEventBus()
+	_, cancel := bus.Subscribe(1)
+	bus.Emit(Event{Kind: EventJoin})
+	bus.Emit(Event{Kind: EventLeave})
+	if bus.Dropped() != 1 {
+		t.Errorf("Expected one dropped delivery, got %d", bus.Dropped())
+	}
+	cancel()
+	cancel()
+	bus.Emit(Event{Kind: EventJoin})
+	if bus.Dropped() != 1 {
+		t.Error("Expected nothing delivered after cancel")
+	}
+}
+
+func TestEventLogWritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	l, err := OpenEventLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	EventSinks{l}.Emit(Event{Kind: EventRename, Name: "bob", OldName: "ada"})
+	l.Emit(Event{Kind: EventRoundEnd, Ticks: 9})
+	l.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[0]["OldName"] != "ada" || lines[1]["Ticks"] != 9.0 {
+		t.Fatalf("Unexpected log: %v", lines)
+	}
+	if _, ok := lines[1]["Name"]; ok {
+		t.Error("Expected unset fields left out")
+	}
+}
+
+// stuckFile is a file whose writes wait until release is closed. Each
+// write first signals writing.
+type stuckFile struct{ writing, release chan struct{} }
+
+func (f stuckFile) Write(b []byte) (int, error) {
+	trySend(f.writing, struct{}{})
+	<-f.release
+	return len(b), nil
+}
+func (f stuckFile) Close() error { return nil }
+
+func TestEventLogDropsWhenWriterIsBehind(t *testing.T) {
+	file := stuckFile{writing: make(chan struct{}, 1), release: make(chan struct{})}
+	l := newEventLog(file, 2)
+	// The writer takes one event and blocks writing it; two more fill
+	// the queue and the rest are dropped.
+	l.Emit(Event{Kind: EventJoin})
+	<-file.writing
+	for i := 0; i < 5; i++ {
+		l.Emit(Event{Kind: EventJoin})
+	}
+	if l.Dropped() != 3 {
+		t.Errorf("Expected 3 events dropped, got %d", l.Dropped())
+	}
+	close(file.release)
+	l.Close()
+}
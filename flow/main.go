@@ -1,49 +1,244 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"strconv"
+	"time"
 
 	"github.com/balboah/flow"
 )
 
+// defaults only feeds the flag help; flags override the config file and
+// environment only when given.
+var defaults = flow.DefaultConfig()
+
 var (
-	root = flag.String("www", "html", "Web root to serve from")
-	port = flag.Int("port", 5000, "Port to listen on")
+	configPath  = flag.String("config", "", "JSON config file (see -print-config for the format)")
+	printConfig = flag.Bool("print-config", false, "Print the resolved configuration as JSON and exit")
+
+	root         = flag.String("www", defaults.Server.WWW, "Web root to serve from")
+	port         = flag.Int("port", defaults.Server.Port, "Port to listen on")
+	board        = flag.String("leaderboard", defaults.Server.Leaderboard, "File the high-score leaderboard is kept in; empty keeps it in memory")
+	players      = flag.String("profiles", defaults.Server.Profiles, "File player profiles are kept in; empty keeps them in memory")
+	profileFlush = flag.Duration("profile-flush", time.Duration(defaults.Server.ProfileFlushInterval), "How often changed profiles are written")
+	replays      = flag.String("replays", defaults.Server.Replays, "Directory to record match replays into; empty records none")
+	replayTicks  = flag.Int("replay-max-ticks", defaults.Server.ReplayMaxTicks, "Ticks each replay file runs before the next starts")
+	replayKeep   = flag.Int("replay-keep", defaults.Server.ReplayKeep, "Replay files to keep; older ones are deleted")
+	eventLog     = flag.String("events", defaults.Server.Events, "File to append game events to as JSON lines; empty writes none")
+	perIP        = flag.Int("max-conns-per-ip", defaults.Limits.MaxConnsPerIP, "Most connections from one address")
+	maxConns     = flag.Int("max-conns", defaults.Limits.MaxConns, "Most connections in total")
+	origins      = flag.String("allowed-origins", "", "Comma-separated websocket origins to accept; empty accepts any")
+
+	tick         = flag.Int("tick", defaults.Rules.Tick, "Milliseconds per tick")
+	disconnect   = flag.Duration("disconnect-ttl", time.Duration(defaults.Rules.DisconnectTTL), "How long a disconnected player's worm stays")
+	botDeadline  = flag.Duration("bot-move-deadline", time.Duration(defaults.Rules.BotMoveDeadline), "How long a bot API client has to answer a STATE; shorter than -tick")
+	foodCount    = flag.Int("food-count", defaults.Rules.FoodCount, "Foods kept on the field")
+	remainsEvery = flag.Int("remains-every", defaults.Rules.RemainsEvery, "A dead worm leaves remains on every this many body cells")
+	comboWindow  = flag.Int("combo-window", defaults.Rules.Combo.Window, "Ticks after eating within which the next eat raises the multiplier")
+	comboMax     = flag.Int("combo-max", defaults.Rules.Combo.MaxMultiplier, "Highest combo multiplier")
+	foods        = flag.String("foods", "", "JSON food catalogue to use instead of the built-in one")
+	difficulty   = flag.String("difficulty", string(defaults.Rules.Difficulty), "Bot difficulty: easy, normal, hard or nightmare")
+	adaptive     = flag.Bool("adaptive", defaults.Rules.AdaptiveDifficulty, "Adapt bot difficulty to the best human's score")
+	personas     = flag.String("personas", "", "JSON personas (see cmd/flow-tune) to hand out to bots")
+	botMix       = flag.String("bot-mix", "", "Comma-separated strategy:weight pairs to split bots between, e.g. classic:2,greedy")
+	bots         = flag.Int("bots", defaults.Rules.Bots, "Bots per room while a human is online; -1 tops rooms up to -min-players")
+	minPlayers   = flag.Int("min-players", defaults.Rules.MinPlayers, "Players (humans and bots) -bots -1 tops rooms up to")
+	noPacMan     = flag.Bool("no-pacman", defaults.Rules.NoPacMan, "Keep Pac-Men off the field")
+	perPacMan    = flag.Int("humans-per-pacman", defaults.Rules.HumansPerPacMan, "Connected humans per Pac-Man")
+	maxPacMen    = flag.Int("max-pacmen", defaults.Rules.MaxPacMen, "Most Pac-Men per room")
+	frightened   = flag.Int("pacman-frightened-ticks", defaults.Rules.PacManFrightenedTicks, "Ticks a power pellet frightens Pac-Man for")
+	pacManPoints = flag.Int("pacman-eat-points", defaults.Rules.PacManEatPoints, "Points for eating a frightened Pac-Man")
+	pacManDelay  = flag.Int("pacman-respawn-delay", defaults.Rules.PacManRespawnDelay, "Ticks an eaten Pac-Man stays away")
+	biteRemains  = flag.Bool("bite-remains", defaults.Rules.BiteRemains, "Turn segments Pac-Man bites off into remains")
+	killPoints   = flag.Int("kill-points", defaults.Rules.KillPoints, "Points for a worm another crashes into")
+	cooldown     = flag.Int("respawn-cooldown", defaults.Rules.RespawnCooldown, "Ticks between a death and the next respawn")
+	protection   = flag.Int("spawn-protection", defaults.Rules.SpawnProtection, "Ticks a fresh worm is shielded from Pac-Man and other worms")
+	portals      = flag.Int("portals", defaults.Rules.PortalPairs, "Portal pairs per room")
 )
 
 func main() {
 	flag.Parse()
 
-	// Cloud Run (and other PaaS) inject $PORT. Honor it when the user hasn't
-	// explicitly overridden via -port, so the same binary works locally and
-	// in production without flag wrangling.
-	if env, ok := os.LookupEnv("PORT"); ok && !flagSet("port") {
-		if p, err := strconv.Atoi(env); err == nil {
-			*port = p
+	cfg := flow.DefaultConfig()
+	if *configPath != "" {
+		var err error
+		if cfg, err = flow.LoadConfig(*configPath); err != nil {
+			log.Fatalf("Loading config: %v", err)
+		}
+	}
+	// Cloud Run (and other PaaS) inject $PORT. Env beats the file and
+	// flags beat env, so the same binary and file work locally and in
+	// production without flag wrangling.
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		log.Fatalf("Reading environment: %v", err)
+	}
+	if err := applyFlags(&cfg); err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if *printConfig {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	flow.SetLimits(cfg.Limits)
+	flow.SetRules(cfg.Rules)
+
+	lb, err := flow.OpenLeaderboard(cfg.Server.Leaderboard)
+	if err != nil {
+		log.Fatalf("Opening leaderboard: %v", err)
+	}
+	defer lb.Close()
+	flow.SetLeaderboard(lb)
+
+	ps, err := flow.OpenProfiles(cfg.Server.Profiles, time.Duration(cfg.Server.ProfileFlushInterval))
+	if err != nil {
+		log.Fatalf("Opening profiles: %v", err)
+	}
+	defer ps.Close()
+	flow.SetProfiles(ps)
+
+	if dir := cfg.Server.Replays; dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			log.Fatalf("Creating replay directory: %v", err)
 		}
+		flow.SetReplayDir(dir)
+		flow.SetReplayLimits(cfg.Server.ReplayMaxTicks, cfg.Server.ReplayKeep)
 	}
 
-	addr := fmt.Sprintf(":%v", *port)
+	if path := cfg.Server.Events; path != "" {
+		el, err := flow.OpenEventLog(path)
+		if err != nil {
+			log.Fatalf("Opening event log: %v", err)
+		}
+		defer el.Close()
+		flow.SetEvents(el)
+	}
+
+	addr := fmt.Sprintf(":%v", cfg.Server.Port)
 	log.Printf("Starting flow server at %v\n", addr)
 	http.Handle("/worms", flow.WormsHandler())
-	http.Handle("/", http.FileServer(http.Dir(*root)))
+	http.Handle("/leaderboard", flow.LeaderboardHandler())
+	http.Handle("GET /players/{id}", flow.PlayersHandler())
+	http.Handle("GET /replays", flow.ReplaysHandler())
+	http.Handle("GET /metrics", flow.MetricsHandler())
+	http.Handle("/admin/", flow.AdminHandler())
+	http.Handle("/replays/{name}/watch", flow.ReplayWatchHandler())
+	http.Handle("/", http.FileServer(http.Dir(cfg.Server.WWW)))
 
 	if err := http.ListenAndServe(addr, nil); err != nil {
 		log.Fatalf("ListenAndServe: %v", err)
 	}
 }
 
-func flagSet(name string) bool {
-	set := false
+// applyFlags copies every flag given on the command line into cfg.
+func applyFlags(cfg *flow.Config) error {
+	var err error
 	flag.Visit(func(f *flag.Flag) {
-		if f.Name == name {
-			set = true
+		if err != nil {
+			return
+		}
+		switch f.Name {
+		case "www":
+			cfg.Server.WWW = *root
+		case "port":
+			cfg.Server.Port = *port
+		case "leaderboard":
+			cfg.Server.Leaderboard = *board
+		case "profiles":
+			cfg.Server.Profiles = *players
+		case "profile-flush":
+			cfg.Server.ProfileFlushInterval = flow.Duration(*profileFlush)
+		case "replays":
+			cfg.Server.Replays = *replays
+		case "replay-max-ticks":
+			cfg.Server.ReplayMaxTicks = *replayTicks
+		case "replay-keep":
+			cfg.Server.ReplayKeep = *replayKeep
+		case "events":
+			cfg.Server.Events = *eventLog
+		case "max-conns-per-ip":
+			cfg.Limits.MaxConnsPerIP = *perIP
+		case "max-conns":
+			cfg.Limits.MaxConns = *maxConns
+		case "allowed-origins":
+			cfg.Limits.AllowedOrigins = flow.SplitList(*origins)
+		case "tick":
+			cfg.Rules.Tick = *tick
+		case "disconnect-ttl":
+			cfg.Rules.DisconnectTTL = flow.Duration(*disconnect)
+		case "bot-move-deadline":
+			cfg.Rules.BotMoveDeadline = flow.Duration(*botDeadline)
+		case "food-count":
+			cfg.Rules.FoodCount = *foodCount
+		case "remains-every":
+			cfg.Rules.RemainsEvery = *remainsEvery
+		case "combo-window":
+			cfg.Rules.Combo.Window = *comboWindow
+		case "combo-max":
+			cfg.Rules.Combo.MaxMultiplier = *comboMax
+		case "foods":
+			var catalogue flow.FoodCatalogue
+			if catalogue, err = flow.LoadFoodCatalogue(*foods); err != nil {
+				err = fmt.Errorf("Loading foods: %w", err)
+				return
+			}
+			cfg.Rules.Foods = catalogue
+		case "difficulty":
+			cfg.Rules.Difficulty = flow.Difficulty(*difficulty)
+		case "adaptive":
+			cfg.Rules.AdaptiveDifficulty = *adaptive
+		case "personas":
+			var ps flow.Personas
+			if ps, err = flow.LoadPersonas(*personas); err != nil {
+				err = fmt.Errorf("Loading personas: %w", err)
+				return
+			}
+			cfg.Rules.Personas = ps
+		case "bot-mix":
+			var mix flow.BotMix
+			if mix, err = flow.ParseBotMix(*botMix); err != nil {
+				err = fmt.Errorf("Parsing -bot-mix: %w", err)
+				return
+			}
+			cfg.Rules.BotMix = mix
+		case "bots":
+			cfg.Rules.Bots = *bots
+		case "min-players":
+			cfg.Rules.MinPlayers = *minPlayers
+		case "no-pacman":
+			cfg.Rules.NoPacMan = *noPacMan
+		case "humans-per-pacman":
+			cfg.Rules.HumansPerPacMan = *perPacMan
+		case "max-pacmen":
+			cfg.Rules.MaxPacMen = *maxPacMen
+		case "pacman-frightened-ticks":
+			cfg.Rules.PacManFrightenedTicks = *frightened
+		case "pacman-eat-points":
+			cfg.Rules.PacManEatPoints = *pacManPoints
+		case "pacman-respawn-delay":
+			cfg.Rules.PacManRespawnDelay = *pacManDelay
+		case "bite-remains":
+			cfg.Rules.BiteRemains = *biteRemains
+		case "kill-points":
+			cfg.Rules.KillPoints = *killPoints
+		case "respawn-cooldown":
+			cfg.Rules.RespawnCooldown = *cooldown
+		case "spawn-protection":
+			cfg.Rules.SpawnProtection = *protection
+		case "portals":
+			cfg.Rules.PortalPairs = *portals
 		}
 	})
-	return set
+	return err
 }
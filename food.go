@@ -1,7 +1,16 @@
 package flow
 
-import "math/rand/v2"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+)
 
+// FoodType is the id of a FoodKind in the playfield's FoodCatalogue. The
+// constants below are the kinds the server itself refers to; a catalogue
+// may define any number of others.
 type FoodType string
 
 const (
@@ -10,62 +19,238 @@ const (
 	Broccoli FoodType = "broccoli"
 	// Bomb is a hazard, not a reward — eating one kills the worm.
 	Bomb FoodType = "bomb"
+	// Remains are what a dead worm leaves behind. They have no spawn
+	// weight so randomFood never rolls them; they only appear via
+	// spawnRemains and don't count toward FoodCount.
+	Remains FoodType = "remains"
+	// PowerPellet frightens Pac-Man (see PacMan.Frighten) so the worms
+	// can hunt him for a while.
+	PowerPellet FoodType = "pellet"
+	// Mouse runs away from whoever is closest (see FoodKind.Flee).
+	Mouse FoodType = "mouse"
+	// TimeBomb arms when a head passes by and explodes shortly after
+	// (see FoodKind.Fuse).
+	TimeBomb FoodType = "timebomb"
 )
 
-// FoodCount is how many food items are kept on the field at all times.
+// FoodCount is how many food items are kept on the field by default.
+// Remains are on top of this — they never trigger a replacement spawn.
 const FoodCount = 5
 
-// PointsPerFood maps a food type to its score reward. Bomb is zero because
-// the worm dies before it could be scored. Broccoli is the jackpot — bots
-// actively chase it (see AIFoodAttraction), so a human has to outmanoeuvre
-// the swarm to claim one.
-var PointsPerFood = map[FoodType]int{
-	Apple:    10,
-	Carrot:   5,
-	Broccoli: 25,
-	Bomb:     0,
+// RemainsEvery is the body-cell stride used when a dead worm is turned into
+// remains: every RemainsEvery-th cell from the head back becomes food, so a
+// worm leaves roughly len/RemainsEvery items and longer worms are worth
+// more to scavenge.
+const RemainsEvery = 2
+
+// FoodKind is one entry of a FoodCatalogue. The catalogue is sent to
+// clients in WELCOME, so a new kind only needs a catalogue entry — no
+// server or client code change.
+type FoodKind struct {
+	Id     FoodType
+	Emoji  string // what clients draw for it
+	Points int
+	// Weight is the relative chance randomFood rolls this kind. Zero
+	// means it is never rolled and only appears through game events.
+	Weight int
+	// MaxActive caps how many of this kind may be on the field at once;
+	// zero means no cap. Without a cap on bombs, repeated rolls would
+	// gradually replace every fruit until there is nothing edible left.
+	MaxActive int
+	// Attraction biases the AI's nearest-food picker. The value is
+	// subtracted from the wrap-aware manhattan distance, so a higher
+	// number makes bots willing to detour further for this kind.
+	Attraction int
+	// Lethal kinds kill the worm that eats them instead of scoring.
+	// Cause is the DeathCause reported for it; empty means CauseFood.
+	Lethal bool
+	Cause  DeathCause
+	// Lifetime is how many ticks the food stays before it expires and,
+	// unless it is remains, respawns elsewhere; zero means it stays until
+	// eaten.
+	Lifetime int
+	// Flee makes the food step one cell away from the nearest head every
+	// Flee ticks; zero means it never moves.
+	Flee int
+	// Fuse turns a kind into a timed bomb: a worm head passing next to it
+	// arms it, and Fuse ticks later it explodes, killing heads and
+	// cutting bodies within BlastRadius (manhattan) of it. Zero means it
+	// never arms.
+	Fuse        int
+	BlastRadius int
+}
+
+// FoodCatalogue lists every kind of food a playfield knows about.
+type FoodCatalogue []FoodKind
+
+// DefaultFoodCatalogue is the stock menu. Spawn weights work out to roughly
+// 17% bomb, 8% time bomb, 25% apple, 25% carrot, 12% broccoli, 8% mouse and
+// 4% power pellet — so on average 1 of the 5 active foods is some kind of
+// bomb at any given time, and a pellet turns up every few dozen spawns. Broccoli is the
+// jackpot the swarm fights over, but it wilts after 20s; the mouse is worth
+// more still and runs for it. Pellets get a smaller pull so bots
+// occasionally turn the tables on Pac-Man too. The pellet's real prize is
+// the PacManEatPoints it unlocks.
+func DefaultFoodCatalogue() FoodCatalogue {
+	return FoodCatalogue{
+		{Id: Apple, Emoji: "🍎", Points: 10, Weight: 6},
+		{Id: Carrot, Emoji: "🥕", Points: 5, Weight: 6},
+		{Id: Broccoli, Emoji: "🥦", Points: 25, Weight: 3, Attraction: 6, Lifetime: 100},
+		{Id: Mouse, Emoji: "🐁", Points: 30, Weight: 2, MaxActive: 1, Attraction: 4, Flee: 3},
+		{Id: Bomb, Emoji: "💣", Weight: 4, MaxActive: 2, Lethal: true, Cause: CauseBomb},
+		{Id: TimeBomb, Emoji: "🧨", Weight: 2, MaxActive: 1, Lethal: true, Cause: CauseBomb, Fuse: 10, BlastRadius: 3},
+		{Id: PowerPellet, Emoji: "💊", Points: 10, Weight: 1, MaxActive: 1, Attraction: 3},
+		{Id: Remains, Emoji: "🍖", Points: 5, Lifetime: 50}, // 10s at the default Tick
+	}
+}
+
+// LoadFoodCatalogue reads a JSON array of FoodKind objects from path and
+// validates it.
+func LoadFoodCatalogue(path string) (FoodCatalogue, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c FoodCatalogue
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("food catalogue %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("food catalogue %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate rejects catalogues the playfield can't run with: duplicate or
+// empty ids, negative numbers, or nothing that randomFood could ever roll.
+// MaxActive caps may still leave the field short of FoodCount; spawnFood
+// then places nothing until something is eaten.
+func (c FoodCatalogue) Validate() error {
+	seen := map[FoodType]bool{}
+	rollable := false
+	for _, k := range c {
+		if k.Id == "" {
+			return errors.New("food kind without an id")
+		}
+		if seen[k.Id] {
+			return fmt.Errorf("duplicate food kind %q", k.Id)
+		}
+		seen[k.Id] = true
+		if k.Weight < 0 || k.MaxActive < 0 || k.Lifetime < 0 || k.Flee < 0 || k.Fuse < 0 || k.BlastRadius < 0 {
+			return fmt.Errorf("food kind %q: counts, ticks and radii must not be negative", k.Id)
+		}
+		if k.Weight > 0 && !k.Lethal {
+			rollable = true
+		}
+	}
+	if !rollable {
+		return errors.New("no edible food kind with a spawn weight")
+	}
+	return nil
+}
+
+// Kind looks up the entry for t.
+func (c FoodCatalogue) Kind(t FoodType) (FoodKind, bool) {
+	for _, k := range c {
+		if k.Id == t {
+			return k, true
+		}
+	}
+	return FoodKind{}, false
+}
+
+// deathCause is what eating a Lethal k dies of.
+func (k FoodKind) deathCause() DeathCause {
+	if k.Cause == "" {
+		return CauseFood
+	}
+	return k.Cause
 }
 
-// AIFoodAttraction biases the AI's nearest-food picker. The value is
-// subtracted from the wrap-aware manhattan distance, so a higher number
-// makes the bot willing to detour further for that food type. Broccoli is
-// the only non-zero entry because it's the high-value prize we want the
-// swarm to fight over.
-var AIFoodAttraction = map[FoodType]int{
-	Broccoli: 6,
+// Points is the score reward for eating a t; zero for unknown kinds.
+func (c FoodCatalogue) Points(t FoodType) int {
+	k, _ := c.Kind(t)
+	return k.Points
+}
+
+// roll picks a weighted random kind, leaving out the ones whose MaxActive
+// cap active already meets. Returns false if nothing is left to roll.
+func (c FoodCatalogue) roll(rng *rand.Rand, active map[FoodType]int) (FoodKind, bool) {
+	total := 0
+	for _, k := range c {
+		if k.Weight > 0 && (k.MaxActive == 0 || active[k.Id] < k.MaxActive) {
+			total += k.Weight
+		}
+	}
+	if total == 0 {
+		return FoodKind{}, false
+	}
+	r := rng.IntN(total)
+	for _, k := range c {
+		if k.Weight <= 0 || (k.MaxActive != 0 && active[k.Id] >= k.MaxActive) {
+			continue
+		}
+		if r < k.Weight {
+			return k, true
+		}
+		r -= k.Weight
+	}
+	return FoodKind{}, false
 }
 
 type Food struct {
 	Id       Id
 	Position Position
 	Type     FoodType
+
+	// expiresAt is the playfield tick at which the food fades away. Zero
+	// means it stays until eaten.
+	expiresAt int
+
+	// fuse counts down the ticks until an armed bomb explodes; zero
+	// means unarmed. armedBy is the worm whose head set it off, credited
+	// with whatever the blast kills.
+	fuse    int
+	armedBy *Worm
 }
 
-func (f Food) Points() int {
-	return PointsPerFood[f.Type]
+// Armed reports whether the food is a bomb with a burning fuse.
+func (f *Food) Armed() bool { return f.fuse > 0 }
+
+// blastCells returns every cell within radius (manhattan, wrap-aware) of
+// centre.
+func blastCells(centre Position, radius int) []Position {
+	n := Boundary + 1
+	var out []Position
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if abs(dx)+abs(dy) > radius {
+				continue
+			}
+			out = append(out, Position{
+				X: ((centre.X+dx)%n + n) % n,
+				Y: ((centre.Y+dy)%n + n) % n,
+			})
+		}
+	}
+	return out
 }
 
-// randomFood picks a uniformly random position on the field and a weighted
-// random type. avoid lists positions where food may not spawn (e.g. worm
-// bodies). Distribution: 20% bomb, 30% apple, 30% carrot, 20% broccoli — so
-// on average 1 of the 5 active foods is a bomb at any given time.
-func randomFood(id Id, avoid map[Position]struct{}) Food {
+// randomFood picks a uniformly random position on the field and a random
+// kind from catalogue (see FoodCatalogue.roll). avoid lists positions where
+// food may not spawn (e.g. worm bodies); active counts the kinds already on
+// the field so MaxActive caps hold. Returns false when every kind is at
+// its cap.
+func randomFood(rng *rand.Rand, id Id, avoid map[Position]struct{}, catalogue FoodCatalogue, active map[FoodType]int) (Food, FoodKind, bool) {
+	kind, ok := catalogue.roll(rng, active)
+	if !ok {
+		return Food{}, FoodKind{}, false
+	}
 	for {
-		pos := Position{X: rand.IntN(Boundary + 1), Y: rand.IntN(Boundary + 1)}
+		pos := Position{X: rng.IntN(Boundary + 1), Y: rng.IntN(Boundary + 1)}
 		if _, taken := avoid[pos]; taken {
 			continue
 		}
-		var t FoodType
-		switch r := rand.IntN(10); {
-		case r < 2:
-			t = Bomb
-		case r < 5:
-			t = Apple
-		case r < 8:
-			t = Carrot
-		default:
-			t = Broccoli
-		}
-		return Food{Id: id, Position: pos, Type: t}
+		return Food{Id: id, Position: pos, Type: kind.Id}, kind, true
 	}
 }
@@ -1,6 +1,10 @@
 package flow
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestAddScoreGrows(t *testing.T) {
 	w := NewWorm()
@@ -77,7 +81,7 @@ func TestFoodSpawnsAvoidOccupied(t *testing.T) {
 	p.addMovable(w)
 
 	for i := 0; i < 20; i++ {
-		f := p.spawnFood()
+		f, _ := p.spawnFood()
 		if f.Position.Y == 0 {
 			t.Fatalf("Food spawned on occupied row 0 at %v", f.Position)
 		}
@@ -117,8 +121,8 @@ func TestCollisionEatsFoodAndScores(t *testing.T) {
 
 	p.resolveFoodCollisions()
 
-	if w.Score != PointsPerFood[Apple] {
-		t.Errorf("Expected score %d, got %d", PointsPerFood[Apple], w.Score)
+	if w.Score != p.Rules.Foods.Points(Apple) {
+		t.Errorf("Expected score %d, got %d", p.Rules.Foods.Points(Apple), w.Score)
 	}
 	if _, still := p.Foods[target.Id]; still {
 		t.Errorf("Food should have been removed after collision")
@@ -147,3 +151,292 @@ func TestCollisionEatsFoodAndScores(t *testing.T) {
 	}
 	_ = id
 }
+
+func TestRemainsDoNotCountTowardFoodCount(t *testing.T) {
+	p := NewPlayfield()
+	p.spawnRemains([]Position{{1, 1}, {2, 1}, {3, 1}})
+	w := NewWorm()
+	id := p.addMovable(w)
+	p.announceJoin(w, id)
+
+	if got := p.regularFoodCount(); got != FoodCount {
+		t.Errorf("Expected %d regular foods next to remains, got %d", FoodCount, got)
+	}
+}
+
+func TestEatingRemainsSpawnsNoReplacement(t *testing.T) {
+	p := NewPlayfield()
+	w := NewWorm()
+	p.addMovable(w)
+	p.LastFoodId++
+	p.Foods[p.LastFoodId] = &Food{Id: p.LastFoodId, Position: w.Head(), Type: Remains}
+
+	p.resolveFoodCollisions()
+
+	if w.Score != p.Rules.Foods.Points(Remains) {
+		t.Errorf("Expected score %d, got %d", p.Rules.Foods.Points(Remains), w.Score)
+	}
+	if len(p.Foods) != 0 {
+		t.Errorf("Remains should not be replaced, got %d foods", len(p.Foods))
+	}
+}
+
+func TestRemainsExpire(t *testing.T) {
+	p := NewPlayfield()
+	p.spawnRemains([]Position{{1, 1}})
+	kind, _ := p.Rules.Foods.Kind(Remains)
+	for i := 0; i < kind.Lifetime-1; i++ {
+		p.tick()
+	}
+	if len(p.Foods) != 1 {
+		t.Fatalf("Remains should still be on the field before their lifetime ends")
+	}
+	p.tick()
+	if len(p.Foods) != 0 {
+		t.Errorf("Remains should fade after %d ticks", kind.Lifetime)
+	}
+}
+
+func TestDefaultFoodCatalogueIsValid(t *testing.T) {
+	if err := DefaultFoodCatalogue().Validate(); err != nil {
+		t.Fatalf("Default catalogue rejected: %v", err)
+	}
+}
+
+func TestFoodCatalogueRejectsDuplicates(t *testing.T) {
+	c := FoodCatalogue{
+		{Id: Apple, Points: 10, Weight: 1},
+		{Id: Apple, Points: 5, Weight: 1},
+	}
+	if err := c.Validate(); err == nil {
+		t.Errorf("Duplicate ids should be rejected")
+	}
+}
+
+func TestLoadFoodCatalogue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foods.json")
+	raw := `[{"Id": "cherry", "Emoji": "🍒", "Points": 15, "Weight": 1},
+		{"Id": "mine", "Weight": 1, "MaxActive": 1, "Lethal": true}]`
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadFoodCatalogue(path)
+	if err != nil {
+		t.Fatalf("LoadFoodCatalogue: %v", err)
+	}
+	if got := c.Points("cherry"); got != 15 {
+		t.Errorf("Expected cherry to be worth 15, got %d", got)
+	}
+}
+
+func TestSpawnFoodRespectsMaxActive(t *testing.T) {
+	p := NewPlayfield()
+	p.Rules.Foods = FoodCatalogue{
+		{Id: Apple, Points: 10, Weight: 1},
+		{Id: Bomb, Weight: 1000, MaxActive: 2, Lethal: true},
+	}
+	for i := 0; i < 20; i++ {
+		p.spawnFood()
+	}
+	bombs := 0
+	for _, f := range p.Foods {
+		if f.Type == Bomb {
+			bombs++
+		}
+	}
+	if bombs != 2 {
+		t.Errorf("Expected bombs capped at 2, got %d", bombs)
+	}
+}
+
+func TestSpawnFoodSkipsWhenEveryKindIsCapped(t *testing.T) {
+	p := NewPlayfield()
+	p.Rules.Foods = FoodCatalogue{
+		{Id: Apple, Points: 10, Weight: 1, MaxActive: 2},
+		{Id: Bomb, Weight: 1, MaxActive: 1, Lethal: true},
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok := p.spawnFood(); !ok {
+			t.Fatalf("Expected food %d to spawn under the caps", i+1)
+		}
+	}
+	if f, ok := p.spawnFood(); ok {
+		t.Fatalf("Expected nothing spawned with every kind capped, got %+v", f)
+	}
+	if len(p.Foods) != 3 {
+		t.Errorf("Expected 3 foods, got %d", len(p.Foods))
+	}
+	for _, f := range p.Foods {
+		if f.Type == "" {
+			t.Errorf("Food %d spawned without a kind", f.Id)
+		}
+	}
+
+	// Seeding the field stops at the caps instead of spinning.
+	w := NewWorm()
+	p.announceJoin(w, p.addMovable(w))
+	if len(p.Foods) != 3 {
+		t.Errorf("Expected joining to leave the capped field alone, got %d foods", len(p.Foods))
+	}
+}
+
+func TestLethalCatalogueFoodKills(t *testing.T) {
+	p := NewPlayfield()
+	p.Rules.Foods = append(DefaultFoodCatalogue(), FoodKind{Id: "mine", Lethal: true})
+	w := NewWorm()
+	p.addMovable(w)
+	p.LastFoodId++
+	p.Foods[p.LastFoodId] = &Food{Id: p.LastFoodId, Position: w.Head(), Type: "mine"}
+
+	p.resolveFoodCollisions()
+
+	if !w.killed {
+		t.Errorf("Eating a lethal kind should kill the worm")
+	}
+	if w.deathCause != CauseFood {
+		t.Errorf("Expected a kind without a Cause to report %q, got %q", CauseFood, w.deathCause)
+	}
+}
+
+func TestLethalFoodReportsItsCause(t *testing.T) {
+	for _, kind := range []FoodType{"toadstool", Bomb} {
+		p := NewPlayfield()
+		p.Rules.Foods = append(DefaultFoodCatalogue(), FoodKind{Id: "toadstool", Lethal: true, Cause: "poison"})
+		w := NewWorm()
+		p.addMovable(w)
+		p.LastFoodId++
+		p.Foods[p.LastFoodId] = &Food{Id: p.LastFoodId, Position: w.Head(), Type: kind}
+		p.resolveFoodCollisions()
+		want, _ := p.Rules.Foods.Kind(kind)
+		if !w.killed || w.deathCause != want.Cause {
+			t.Errorf("Expected eating %s to die of %q, got killed=%v cause %q", kind, want.Cause, w.killed, w.deathCause)
+		}
+	}
+}
+
+func TestExpiredFoodRespawnsElsewhere(t *testing.T) {
+	p := NewPlayfield()
+	p.LastFoodId++
+	old := Food{Id: p.LastFoodId, Position: Position{3, 3}, Type: Broccoli, expiresAt: p.ticks + 1}
+	p.Foods[old.Id] = &old
+
+	p.tick()
+
+	if _, still := p.Foods[old.Id]; still {
+		t.Fatalf("Expired food should be removed")
+	}
+	if len(p.Foods) != 1 {
+		t.Errorf("Expected a replacement food, got %d foods", len(p.Foods))
+	}
+	sawExpire := false
+	for len(p.Broadcast) > 0 {
+		pkt := <-p.Broadcast
+		if pkt.Command == "FOOD_EXPIRE" && pkt.Payload.(FoodExpirePayload).FoodId == old.Id {
+			sawExpire = true
+		}
+	}
+	if !sawExpire {
+		t.Errorf("Expected FOOD_EXPIRE for food %d", old.Id)
+	}
+}
+
+func TestFleeingFoodMovesAwayFromHead(t *testing.T) {
+	p := NewPlayfield()
+	p.Rules.Foods = append(DefaultFoodCatalogue(), FoodKind{Id: "rabbit", Points: 30, Flee: 1})
+	w := NewWorm()
+	p.addMovable(w)
+	p.LastFoodId++
+	start := wrap(Position{w.Head().X + 3, w.Head().Y})
+	f := &Food{Id: p.LastFoodId, Position: start, Type: "rabbit"}
+	p.Foods[f.Id] = f
+
+	p.fleeFoods()
+
+	if manhattan(f.Position, w.Head()) <= manhattan(start, w.Head()) {
+		t.Errorf("Fleeing food should move away from the head: %v -> %v", start, f.Position)
+	}
+}
+
+func TestNearestFoodSkipsFoodAboutToExpire(t *testing.T) {
+	p := NewPlayfield()
+	from := Position{10, 10}
+	p.Foods[1] = &Food{Id: 1, Position: Position{12, 10}, Type: Apple, expiresAt: p.ticks + 1}
+	p.Foods[2] = &Food{Id: 2, Position: Position{20, 10}, Type: Apple}
+
+	got, ok := nearestFood(from, p)
+	if !ok || got != (Position{20, 10}) {
+		t.Errorf("Expected bots to skip food that expires before they arrive, got %v", got)
+	}
+}
+
+func TestTimeBombArmsWhenHeadPassesBy(t *testing.T) {
+	p := NewPlayfield()
+	w := NewWorm()
+	p.addMovable(w)
+	p.LastFoodId++
+	bomb := &Food{Id: p.LastFoodId, Position: wrap(Position{w.Head().X + 1, w.Head().Y}), Type: TimeBomb}
+	p.Foods[bomb.Id] = bomb
+
+	p.resolveBombs()
+
+	if !bomb.Armed() || bomb.armedBy != w {
+		t.Errorf("A head next to a time bomb should arm it")
+	}
+}
+
+func TestTimeBombBlastKillsAndCuts(t *testing.T) {
+	p := NewPlayfield()
+	kind, _ := p.Rules.Foods.Kind(TimeBomb)
+	centre := Position{20, 20}
+
+	// victim's head sits inside the blast.
+	victim := NewWorm()
+	victim.blocks = []Position{{20, 21}, {20, 22}, {20, 23}}
+	p.addMovable(victim)
+	// cut's head is outside, its tail runs through the blast.
+	cut := NewWorm()
+	cut.blocks = []Position{{14, 20}, {15, 20}, {16, 20}, {17, 20}, {18, 20}}
+	p.addMovable(cut)
+
+	p.LastFoodId++
+	bomb := &Food{Id: p.LastFoodId, Position: centre, Type: TimeBomb, fuse: 1}
+	p.Foods[bomb.Id] = bomb
+
+	killed, pkts, _ := p.resolveBombs()
+
+	if len(killed) != 1 || killed[0] != victim || victim.DeathCause() != CauseBlast {
+		t.Errorf("Expected the head inside the blast to die, got %v", killed)
+	}
+	if _, still := p.Foods[bomb.Id]; still {
+		t.Errorf("Exploded bomb should be removed")
+	}
+	// Radius 3 around (20,20) reaches x=17: cut keeps its first 3 cells.
+	if got := len(cut.blocks); got != 20-kind.BlastRadius-14 {
+		t.Errorf("Expected the body cut at the blast edge, len=%d", got)
+	}
+	sawExplode := false
+	for _, pkt := range pkts {
+		if pkt.Command == "EXPLODE" {
+			sawExplode = true
+		}
+	}
+	if !sawExplode {
+		t.Errorf("Expected an EXPLODE packet")
+	}
+}
+
+func TestBotsFleeArmedBomb(t *testing.T) {
+	p := NewPlayfield()
+	w := NewWorm()
+	w.AI = true
+	w.personality = AIPersonality{Name: "Test"}
+	w.blocks = []Position{{20, 20}, {19, 20}, {18, 20}}
+	w.direction = Right
+	p.addMovable(w)
+	p.LastFoodId++
+	p.Foods[p.LastFoodId] = &Food{Id: p.LastFoodId, Position: Position{22, 20}, Type: TimeBomb, fuse: 5}
+
+	if d := pickAIDirection(w, p); d == Right {
+		t.Errorf("Bot should steer away from an armed bomb, went %v", d)
+	}
+}
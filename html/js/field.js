@@ -16,6 +16,8 @@
 	// pans past an edge the next tile copy comes into view — every food /
 	// remote-worm is drawn 9 times at these offsets so something is always
 	// under the player.
+	var PORTAL_COLORS = ['#00e5ff', '#ff6ec7', '#b2ff59', '#ffab40'];
+
 	var GHOST_OFFSETS = [
 		[ 0,  0], [ 1,  0], [-1,  0], [ 0,  1], [ 0, -1],
 		[ 1,  1], [-1,  1], [ 1, -1], [-1, -1]
@@ -30,9 +32,10 @@
 		this.options = Object.assign({}, Field.defaults, options || {});
 		this.worms = {};
 		this.foods = {};
+		this.portals = [];
 		this.particles = [];
 		this.markers = {};      // id → {x, y, color, opacity, angle} (rad)
-		this.pacman = null;     // null when not on the field
+		this.pacmen = {};       // id → Pacman, one per hunter on the field
 		this.showGrid = false;
 		this.logicalSize = this.options.cols * this.options.grid;
 
@@ -236,6 +239,22 @@
 		this.requestAnimation();
 	};
 
+	// setPortals replaces the portal pairs drawn under everything else.
+	Field.prototype.setPortals = function(portals) {
+		this.portals = portals || [];
+		this.requestAnimation();
+	};
+
+	// moveFood puts a fleeing food on its new cell.
+	Field.prototype.moveFood = function(id, x, y) {
+		var f = this.foods[id];
+		if (f) {
+			f.x = x;
+			f.y = y;
+			this.requestAnimation();
+		}
+	};
+
 	Field.prototype.removeFood = function(id) {
 		var f = this.foods[id];
 		if (f) {
@@ -314,7 +333,9 @@
 					);
 				}
 			}
-			if (self.pacman && self.pacman.tick(now)) anyActive = true;
+			for (var pmId in self.pacmen) {
+				if (self.pacmen[pmId].tick(now)) anyActive = true;
+			}
 
 			if (now - self._lastMarkerTime >= MARKER_THROTTLE_MS) {
 				self._lastMarkerTime = now;
@@ -451,14 +472,40 @@
 			}
 		}
 
+		// 1b. Portals — a ring on each end, one colour per pair so the
+		//     player can tell which ends belong together.
+		for (var pi = 0; pi < this.portals.length; pi++) {
+			var portal = this.portals[pi];
+			ctx.strokeStyle = PORTAL_COLORS[pi % PORTAL_COLORS.length];
+			ctx.lineWidth = 3;
+			var ends = [portal.A, portal.B];
+			for (var e = 0; e < ends.length; e++) {
+				for (var pg = 0; pg < GHOST_OFFSETS.length; pg++) {
+					ctx.beginPath();
+					ctx.arc(
+						ends[e].X * grid + grid / 2 + GHOST_OFFSETS[pg][0] * fieldPx,
+						ends[e].Y * grid + grid / 2 + GHOST_OFFSETS[pg][1] * fieldPx,
+						grid / 2 - 2, 0, Math.PI * 2
+					);
+					ctx.stroke();
+				}
+			}
+		}
+
 		// 2. Foods — 9 copies per food. Broccoli is the jackpot: each copy
 		//    gets a sparkle overlay so the player spots it instantly.
 		var foodIds = Object.keys(this.foods);
 		for (var fi = 0; fi < foodIds.length; fi++) {
 			var f = this.foods[foodIds[fi]];
+			var alpha = f.alpha(now);
+			if (alpha <= 0) {
+				this.removeFood(f.id);
+				continue;
+			}
 			var fx = f.x * grid;
 			var fy = f.y * grid;
 			var isJackpot = f.type === 'broccoli';
+			ctx.globalAlpha = alpha;
 			for (var g = 0; g < GHOST_OFFSETS.length; g++) {
 				var off = GHOST_OFFSETS[g];
 				var ox = fx + off[0] * fieldPx;
@@ -466,6 +513,7 @@
 				ctx.drawImage(f.bitmap, ox, oy);
 				if (isJackpot) drawBroccoliSparkle(ctx, ox, oy, grid, now);
 			}
+			ctx.globalAlpha = 1;
 		}
 
 		// 3. Particles — fade out as they fly outward; drop when done.
@@ -497,6 +545,8 @@
 			var w = this.worms[wormIds[wi]];
 			if (!w.image || !w.image.complete || !w.image.naturalWidth) continue;
 			var copies = (w.useContinuous && this.cameraMode) ? 1 : GHOST_OFFSETS.length;
+			// Spawn protection blinks the whole worm at ~4Hz.
+			ctx.globalAlpha = (w.protected && Math.floor(now / 125) % 2) ? 0.35 : 1;
 			for (var c = 0; c < copies; c++) {
 				var coff = GHOST_OFFSETS[c];
 				var cdx = coff[0] * fieldPx;
@@ -513,19 +563,23 @@
 				}
 			}
 		}
+		ctx.globalAlpha = 1;
 
-		// 4b. Pac-Man — drawn on top of worms so the bite is visually
+		// 4b. Pac-Men — drawn on top of worms so the bite is visually
 		//     unambiguous. Tiled 9× in camera mode to match wrap. The
 		//     drawn size is Pacman.SIZE × grid so his body engulfs
 		//     the worm cells his footprint actually covers.
-		if (this.pacman && this.pacman.visualPx) {
-			var pmSize = Pacman.SIZE * grid;
+		var pmSize = Pacman.SIZE * grid;
+		var pmIds = Object.keys(this.pacmen);
+		for (var pi2 = 0; pi2 < pmIds.length; pi2++) {
+			var pm = this.pacmen[pmIds[pi2]];
+			if (!pm.visualPx) continue;
 			for (var pc = 0; pc < GHOST_OFFSETS.length; pc++) {
 				var poff = GHOST_OFFSETS[pc];
-				this.pacman.drawAt(
+				pm.drawAt(
 					ctx,
-					this.pacman.visualPx.x + poff[0] * fieldPx,
-					this.pacman.visualPx.y + poff[1] * fieldPx,
+					pm.visualPx.x + poff[0] * fieldPx,
+					pm.visualPx.y + poff[1] * fieldPx,
 					pmSize, now
 				);
 			}
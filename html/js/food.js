@@ -1,12 +1,18 @@
 (function(){
 
+	// Fallback glyphs until WELCOME delivers the playfield's catalogue.
 	var FOOD_EMOJI = {
 		apple:    '🍎',
 		carrot:   '🥕',
 		broccoli: '🥦',
-		bomb:     '💣'
+		bomb:     '💣',
+		remains:  '🍖',
+		pellet:   '💊'
 	};
 
+	// Foods with a TTL start fading this many ms before they expire.
+	var FADE_MS = 2000;
+
 	// Pre-render each emoji into an offscreen canvas. We paint the glyph
 	// ourselves via fillText so the same bitmap can be reused across every
 	// food of a given type / size — drawImage of a cached bitmap is much
@@ -39,11 +45,45 @@
 		this.type = payload.Type;
 		this.points = payload.Points;
 		this.bitmap = bitmapFor(FOOD_EMOJI[this.type] || '?', field.options.grid);
+		// TTL (ms) is only set for foods that fade on their own, like the
+		// remains a dead worm leaves. The server confirms with FOOD_EXPIRE;
+		// the TTL just lets us fade them out smoothly beforehand.
+		this.expiresAt = payload.TTL ? performance.now() + payload.TTL : null;
 	}
 
+	// alpha returns the draw opacity at `now`: 1 for permanent foods,
+	// ramping to 0 over the last FADE_MS of a TTL food's life.
+	Food.prototype.alpha = function(now) {
+		if (this.armedAt != null) {
+			// Blink faster as the fuse burns down.
+			var left = Math.max(0, this.fuseMs - (now - this.armedAt));
+			var period = 120 + left / 4;
+			return Math.floor(now / period) % 2 ? 0.35 : 1;
+		}
+		if (this.expiresAt == null) return 1;
+		var left = this.expiresAt - now;
+		if (left <= 0) return 0;
+		return Math.min(1, left / FADE_MS);
+	};
+
+	// arm marks a time bomb whose fuse is burning; alpha blinks it until
+	// the EXPLODE packet removes it.
+	Food.prototype.arm = function(fuseMs) {
+		this.armedAt = performance.now();
+		this.fuseMs = fuseMs;
+	};
+
 	// Kept as a no-op so callers (Field.removeFood) don't need to special-case.
 	Food.prototype.destroy = function() {};
 
+	// register takes the FoodCatalogue from a WELCOME payload and learns
+	// the emoji for every kind it lists.
+	Food.register = function(catalogue) {
+		catalogue.forEach(function(kind){
+			if (kind.Emoji) FOOD_EMOJI[kind.Id] = kind.Emoji;
+		});
+	};
+
 	window.Food = Food;
 
 })();
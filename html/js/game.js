@@ -28,12 +28,20 @@
 		_touchMoveHandler: null,
 		_touchEndHandler: null,
 		_welcomeSubmitHandler: null,
+		// True while the replay seek slider is being dragged.
+		_seeking: false,
 
 		init: function(){
 			game.field = new Field();
 			game.hud = new HUD(game);
 			game.bindGameOver();
 			game.bindMusicPlayer();
+			// ?replay=<name> watches a recorded match instead of playing.
+			var replay = new URLSearchParams(document.location.search).get('replay');
+			if (replay) {
+				game.watch(replay);
+				return;
+			}
 			// Always confirm the alias on page load. Pre-fill with the last
 			// one used so a quick refresh is a single Enter press.
 			game.showWelcome(loadStored(STORAGE_NAME));
@@ -153,15 +161,7 @@
 				console.error('WebSocket Error', error);
 			};
 
-			ws.onmessage = function(ev){
-				var packet = JSON.parse(ev.data);
-				var handler = game.commands[packet.Command.toLowerCase()];
-				if (handler) {
-					handler(packet.Payload);
-				} else {
-					console.warn('Unhandled packet', packet);
-				}
-			};
+			ws.onmessage = game.dispatch;
 
 			ws.onopen = function(){
 				var token = loadStored(STORAGE_TOKEN);
@@ -181,6 +181,93 @@
 			// spin up a fresh worm on its own.
 		},
 
+		// Hands a packet from the server to its command handler.
+		dispatch: function(ev){
+			var packet = JSON.parse(ev.data);
+			var handler = game.commands[packet.Command.toLowerCase()];
+			if (handler) {
+				handler(packet.Payload);
+			} else {
+				console.warn('Unhandled packet', packet);
+			}
+		},
+
+		// Spectates the recorded match `name`. The server streams the
+		// recorded packets through the same handlers a live game uses;
+		// the replay bar steers it with SPEED, SEEK and PAUSE.
+		watch: function(name){
+			var proto = (document.location.protocol === 'https:') ? 'wss:' : 'ws:';
+			var ws = game.ws = new WebSocket(proto + '//' + document.location.host +
+				'/replays/' + encodeURIComponent(name) + '/watch');
+			ws.onerror = function(error){
+				console.error('WebSocket Error', error);
+			};
+			ws.onmessage = game.dispatch;
+			document.getElementById('name-input').hidden = true;
+		},
+
+		// Sends a replay control, only ever on the open replay socket:
+		// send() would reconnect to /worms and join the game.
+		control: function(command, payload){
+			if (game.ws && game.ws.readyState === WebSocket.OPEN) {
+				game.ws.send(JSON.stringify({Command: command, Payload: payload}));
+			}
+		},
+
+		bindReplayBar: function(payload){
+			var bar = document.getElementById('replay-bar');
+			var pause = document.getElementById('replay-pause');
+			var seek = document.getElementById('replay-seek');
+			var speeds = document.getElementById('replay-speeds');
+			var paused = false;
+
+			seek.min = payload.FirstTick;
+			seek.max = payload.LastTick;
+			seek.value = payload.FirstTick;
+			seek.addEventListener('input', function(){
+				game._seeking = true;
+			});
+			seek.addEventListener('change', function(){
+				game._seeking = false;
+				game.control('SEEK', Number(seek.value));
+			});
+			pause.addEventListener('click', function(){
+				paused = !paused;
+				pause.textContent = paused ? '▶' : '⏸';
+				pause.title = paused ? 'Play' : 'Pause';
+				pause.setAttribute('aria-label', pause.title);
+				game.control('PAUSE', paused);
+			});
+			speeds.textContent = '';
+			(payload.Speeds || []).forEach(function(speed, i){
+				var btn = document.createElement('button');
+				btn.type = 'button';
+				btn.textContent = speed + '×';
+				if (i === 0) btn.className = 'active';
+				btn.addEventListener('click', function(){
+					Array.prototype.forEach.call(speeds.children, function(b){
+						b.className = '';
+					});
+					btn.className = 'active';
+					game.control('SPEED', speed);
+				});
+				speeds.appendChild(btn);
+			});
+			bar.hidden = false;
+		},
+
+		// Drops every worm, food and Pac-Man on screen.
+		clearField: function(){
+			Object.keys(game.field.worms).forEach(function(id){
+				game.field.kill(Number(id));
+			});
+			Object.keys(game.field.foods).forEach(function(id){
+				game.field.removeFood(Number(id));
+			});
+			game.hud.scores = {};
+			game.field.pacmen = {};
+		},
+
 		bindControls: function(){
 			// Send a MOVE and give the local head sprite immediate visual
 			// feedback. The server is still authoritative — it'll either
@@ -318,25 +405,21 @@
 		commands: {
 
 			welcome: function(payload) {
+				// The food catalogue tells us how to draw every kind,
+				// including ones this client has never heard of.
+				if (payload.Foods) Food.register(payload.Foods);
+				Worm.setTick(payload.Tick);
+				Pacman.setTick(payload.Tick);
 				// A different Id means the reconnect landed on a fresh
 				// worm: the prior one was swept after DisconnectTTL.
 				// Drop everything we had. Orphan worms (no further MOVE
 				// updates) and orphan food (eaten while we were out)
 				// would otherwise linger forever. Catch-up SCORE/FOOD
 				// packets and the next MOVE tick rebuild the view.
+				// Stale Pac-Man tweens go too; the new session
+				// re-announces them via the per-join PACMAN packets.
 				if (game.hud.ownId != null && game.hud.ownId !== payload.Id) {
-					Object.keys(game.field.worms).forEach(function(id){
-						game.field.kill(Number(id));
-					});
-					Object.keys(game.field.foods).forEach(function(id){
-						game.field.removeFood(Number(id));
-					});
-					game.hud.scores = {};
-					// Drop any stale Pac-Man tween from the prior session;
-					// the new session re-announces him via the per-join
-					// PACMAN packet (or leaves him absent if the new field
-					// has none).
-					game.field.pacman = null;
+					game.clearField();
 				}
 
 				game.hud.welcome(payload);
@@ -359,9 +442,33 @@
 				}
 			},
 
+			replay: function(payload) {
+				if (payload.Foods) Food.register(payload.Foods);
+				Worm.setTick(payload.Tick);
+				Pacman.setTick(payload.Tick);
+				document.title = 'Flow replay: ' + payload.Room;
+				game.bindReplayBar(payload);
+			},
+
+			replay_tick: function(payload) {
+				var seek = document.getElementById('replay-seek');
+				// Leave the thumb alone while it's being dragged.
+				if (!game._seeking) seek.value = payload;
+				document.getElementById('replay-tick').textContent = payload;
+			},
+
+			// A replay SEEK: the server rebuilds the field from scratch.
+			reset: function() {
+				Object.keys(game.hud.scores).forEach(function(id){
+					game.hud.removeWorm(Number(id));
+				});
+				game.clearField();
+			},
+
 			move: function(payload) {
 				var worm = game.field.getWorm(payload.Id);
 				worm.move(payload.Positions);
+				worm.protected = !!payload.Protected;
 				// Camera follow (in camera mode) is driven by Field's rAF
 				// loop so it tweens with the interpolated head rather than
 				// snapping to the end target ahead of the sprite.
@@ -377,6 +484,31 @@
 				game.field.addFood(payload);
 			},
 
+			portals: function(payload) {
+				game.field.setPortals(payload.Portals);
+			},
+
+			arm: function(payload) {
+				var food = game.field.foods[payload.FoodId];
+				if (food) food.arm(payload.Fuse);
+			},
+
+			explode: function(payload) {
+				game.field.removeFood(payload.FoodId);
+				if (payload.Cells && payload.Cells.length) {
+					game.field.explode(payload.Cells);
+				}
+				if (window.sounds) sounds.bomb();
+			},
+
+			food_expire: function(payload) {
+				game.field.removeFood(payload.FoodId);
+			},
+
+			food_move: function(payload) {
+				game.field.moveFood(payload.FoodId, payload.X, payload.Y);
+			},
+
 			eat: function(payload) {
 				var food = game.field.foods[payload.FoodId];
 				if (window.sounds && payload.WormId === game.hud.ownId && food) {
@@ -419,17 +551,28 @@
 				}
 			},
 
+			killfeed: function(payload) {
+				game.hud.killFeed(payload);
+			},
+
+			leaderboard: function(payload) {
+				game.hud.leaderboard(payload);
+			},
+
 			pacman: function(payload) {
-				if (!game.field.pacman) {
-					game.field.pacman = new Pacman(game.field);
+				var pm = game.field.pacmen[payload.Id];
+				if (!pm) {
+					pm = game.field.pacmen[payload.Id] = new Pacman(game.field);
 				}
-				game.field.pacman.update(payload);
+				pm.update(payload);
 			},
 
-			pacman_kill: function() {
-				if (game.field.pacman) {
-					game.field.pacman.kill();
-					game.field.pacman = null;
+			pacman_kill: function(payload) {
+				var id = payload && payload.PacManId;
+				var pm = game.field.pacmen[id];
+				if (pm) {
+					pm.kill();
+					delete game.field.pacmen[id];
 				}
 			},
 
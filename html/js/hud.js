@@ -55,11 +55,77 @@
 	HUD.prototype.updateScore = function(payload) {
 		this.scores[payload.WormId] = {
 			name: payload.Name,
-			score: payload.Score
+			score: payload.Score,
+			multiplier: payload.Multiplier || 1
 		};
 		this.render();
 	};
 
+	// Wording for each server DeathCause. Killer-credited causes read
+	// "killer <verb> victim"; the rest read "victim <verb>".
+	var KILL_VERBS = {
+		crash:   'tripped up',
+		head_on: 'went head-on with',
+		blast:   'blew up'
+	};
+	var DEATH_VERBS = {
+		self:    'ate themselves',
+		bomb:    'stepped on a bomb',
+		food:    'ate something deadly',
+		pacman:  'was eaten by Pac-Man',
+		blast:   'was caught in a blast',
+		crash:   'crashed',
+		head_on: 'went head-on'
+	};
+	var KILLFEED_LINES = 5;
+	var KILLFEED_MS = 5000;
+
+	// killFeed appends one line to the kill feed and fades it out after
+	// KILLFEED_MS. Only the newest KILLFEED_LINES lines are kept.
+	HUD.prototype.killFeed = function(payload) {
+		var el = document.getElementById('killfeed');
+		if (!el) return;
+		var text;
+		if (payload.KillerName && KILL_VERBS[payload.Cause]) {
+			text = payload.KillerName + ' ' + KILL_VERBS[payload.Cause] + ' ' + payload.VictimName;
+		} else {
+			text = payload.VictimName + ' ' + (DEATH_VERBS[payload.Cause] || 'died');
+		}
+		var line = document.createElement('div');
+		line.className = 'line';
+		line.textContent = text;
+		el.appendChild(line);
+		while (el.children.length > KILLFEED_LINES) {
+			el.removeChild(el.firstChild);
+		}
+		setTimeout(function(){ line.classList.add('fading'); }, KILLFEED_MS);
+		setTimeout(function(){
+			if (line.parentNode) line.parentNode.removeChild(line);
+		}, KILLFEED_MS + 600);
+	};
+
+	// leaderboard fills the game-over panel's top list from a LEADERBOARD
+	// packet: today's best, or all-time if nobody has finished a life yet
+	// today.
+	HUD.prototype.leaderboard = function(payload) {
+		var box = document.getElementById('leaderboard');
+		if (!box) return;
+		var lives = payload.Today || [];
+		var title = 'Best today';
+		if (!lives.length) {
+			lives = payload.AllTime || [];
+			title = 'Best ever';
+		}
+		box.hidden = !lives.length;
+		document.getElementById('leaderboard-title').textContent = title;
+		var html = '';
+		for (var i = 0; i < lives.length && i < 5; i++) {
+			html += '<li><span class="name">' + escapeHtml(lives[i].Name) + '</span> ' +
+				'<b>' + lives[i].Score + '</b></li>';
+		}
+		document.getElementById('leaderboard-list').innerHTML = html;
+	};
+
 	HUD.prototype.removeWorm = function(id) {
 		delete this.scores[id];
 		this.render();
@@ -67,7 +133,8 @@
 
 	HUD.prototype.render = function() {
 		if (this.ownId != null && this.scores[this.ownId]) {
-			this.ownScore.textContent = this.scores[this.ownId].score;
+			var own = this.scores[this.ownId];
+			this.ownScore.textContent = own.score + (own.multiplier > 1 ? ' ×' + own.multiplier : '');
 		}
 		var html = '';
 		var ids = Object.keys(this.scores).sort(function(a, b){
@@ -83,6 +150,7 @@
 				'<span class="dot"></span>' +
 				escapeHtml(entry.name) +
 				': ' + entry.score +
+				(entry.multiplier > 1 ? ' <span class="combo">×' + entry.multiplier + '</span>' : '') +
 				'</span>';
 		}
 		this.scoresEl.innerHTML = html;
@@ -1,10 +1,10 @@
 (function(){
 
 	// Match the server tick; same TICK_MS as worms so their motions stay
-	// visually synchronised.
+	// visually synchronised. WELCOME sets the room's (see setTick).
 	var TICK_MS = 200;
 
-	// Pacman holds the single hunter's render state. Update flow:
+	// Pacman holds one hunter's render state. Update flow:
 	//   server PACMAN packet → update(cell, dir) → tween starts
 	//   field rAF loop       → tick(now) advances the tween + mouth phase
 	//   field render         → drawAt(ctx, px, py, size) paints
@@ -13,6 +13,8 @@
 		this.cell = {x: 0, y: 0};
 		this.prevCell = {x: 0, y: 0};
 		this.direction = 'RIGHT';
+		// 'hunting' or 'frightened' (after a worm ate a power pellet).
+		this.state = 'hunting';
 
 		this.startPx = null;
 		this.endPx   = null;
@@ -38,6 +40,7 @@
 		var grid = this.flow.options.grid;
 		var newCell = {x: payload.X, y: payload.Y};
 		this.direction = payload.Direction || this.direction;
+		this.state = payload.State || 'hunting';
 
 		var endPx = {x: newCell.x * grid, y: newCell.y * grid};
 		var snap = false;
@@ -67,7 +70,7 @@
 		var now = performance.now();
 		if (this.lastUpdateAt != null) {
 			var dt = now - this.lastUpdateAt;
-			if (dt > 80 && dt < 500) {
+			if (dt > TICK_MS * 0.4 && dt < TICK_MS * 2.5) {
 				if (this.smoothedTickMs == null) this.smoothedTickMs = dt;
 				else this.smoothedTickMs = this.smoothedTickMs * 0.7 + dt * 0.3;
 			}
@@ -113,8 +116,9 @@
 			case 'UP':    facing = -Math.PI / 2; break;
 		}
 
-		// Body wedge: full circle minus the mouth.
-		ctx.fillStyle = '#ffd23a';
+		// Body wedge: full circle minus the mouth. Frightened he turns
+		// ghost-blue so the worms know they can eat him.
+		ctx.fillStyle = this.state === 'frightened' ? '#3a5bff' : '#ffd23a';
 		ctx.beginPath();
 		ctx.moveTo(cx, cy);
 		ctx.arc(cx, cy, r, facing + halfMouth, facing - halfMouth + 2 * Math.PI);
@@ -149,6 +153,11 @@
 	// occupies on the server.
 	Pacman.SIZE = 2;
 
+	// setTick takes the room's tick from a WELCOME or REPLAY payload.
+	Pacman.setTick = function(ms) {
+		if (ms > 0) TICK_MS = ms;
+	};
+
 	window.Pacman = Pacman;
 
 })();
@@ -1,25 +1,31 @@
 (function(){
 
-	// Server tick interval. The worm interpolates each part from its previous
-	// to its new cell over this window so motion reads as smooth slide rather
-	// than discrete cell-step.
+	// Server tick interval, until WELCOME says the room's (see setTick).
+	// The worm interpolates each part from its previous to its new cell
+	// over this window so motion reads as smooth slide rather than discrete
+	// cell-step.
 	var TICK_MS = 200;
 
 	function randomColor() {
 		return Math.round(50 + Math.random() * 150);
 	}
 
+	// FIELD_SPAN is how far a cell moves when it wraps across an edge.
+	var FIELD_SPAN = 49;
+
 	// stepDelta returns the signed one-cell delta from `from` to `to` along
-	// each axis. The field wraps so a delta of magnitude > 1 means the cells
+	// each axis. The field wraps so a delta of FIELD_SPAN means the cells
 	// are adjacent across an edge; we flip the sign in that case so the
-	// returned delta always represents the *actual* one-cell step.
+	// returned delta always represents the *actual* one-cell step. Any
+	// other jump is a portal, and the raw delta is kept so the worm really
+	// does reappear at the far end.
 	function stepDelta(from, to) {
 		var dx = to.X - from.X;
 		var dy = to.Y - from.Y;
-		if (dx > 1)  dx = -1;
-		if (dx < -1) dx = 1;
-		if (dy > 1)  dy = -1;
-		if (dy < -1) dy = 1;
+		if (dx === FIELD_SPAN)  dx = -1;
+		if (dx === -FIELD_SPAN) dx = 1;
+		if (dy === FIELD_SPAN)  dy = -1;
+		if (dy === -FIELD_SPAN) dy = 1;
 		return {dx: dx, dy: dy};
 	}
 
@@ -209,6 +215,12 @@
 				var dyRaw = positions[i].Y - prevTargetCells[i].Y;
 				if (Math.abs(dxRaw) > 1 || Math.abs(dyRaw) > 1) snap = true;
 			}
+			// Continuous coords only jump when a segment went through a
+			// portal; snap that too instead of sliding across the field.
+			if (!snap && this.useContinuous && prevContinuous && i < prevContinuous.length) {
+				if (Math.abs(newContinuous[i].X - prevContinuous[i].X) > 1 ||
+				    Math.abs(newContinuous[i].Y - prevContinuous[i].Y) > 1) snap = true;
+			}
 			if (snap) {
 				startPx[i] = {x: endPx[i].x, y: endPx[i].y};
 				this.parts[i].x = endPx[i].x;
@@ -236,7 +248,7 @@
 			var observedDt = nowMs - this.lastMoveAt;
 			// Outlier guard: drop intervals outside a plausible band so
 			// a reconnect / tab-resume catch-up doesn't poison the EMA.
-			if (observedDt > 80 && observedDt < 500) {
+			if (observedDt > TICK_MS * 0.4 && observedDt < TICK_MS * 2.5) {
 				if (this.smoothedTickMs == null) this.smoothedTickMs = observedDt;
 				else this.smoothedTickMs = this.smoothedTickMs * 0.7 + observedDt * 0.3;
 			}
@@ -441,6 +453,12 @@
 		}
 	};
 
+	// setTick takes the room's tick from a WELCOME or REPLAY payload, so
+	// the first tweens after joining last as long as a tick does.
+	Worm.setTick = function(ms) {
+		if (ms > 0) TICK_MS = ms;
+	};
+
 	window.Worm = Worm;
 
 })();
@@ -0,0 +1,376 @@
+package flow
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LeaderboardSize is how many lives each top list holds, and the largest
+// n the HTTP endpoint hands out.
+const LeaderboardSize = 100
+
+// leaderboardPacketSize is how many lives per list the LEADERBOARD packet
+// carries.
+const leaderboardPacketSize = 10
+
+// LifeRecord is one finished life: who, where, how well and how it ended.
+type LifeRecord struct {
+	Name   string
+	Room   string
+	Score  int
+	Length int
+	Cause  DeathCause
+	Bot    bool
+	At     time.Time
+}
+
+// Leaderboard keeps every room's finished lives, humans and bots in
+// separate tables so bots can't crowd people out. Records are appended to
+// a JSON-lines file as they come in; once enough have piled up the file is
+// rewritten with only the ones that can still make a list — the all-time
+// or today's top LeaderboardSize, overall or per room. Both happen on a
+// writer goroutine of the leaderboard's own, so Record never waits on the
+// disk. A nil *Leaderboard records nothing, so playfields run fine without
+// one.
+type Leaderboard struct {
+	mu     sync.Mutex
+	path   string
+	humans []LifeRecord
+	bots   []LifeRecord
+	now    func() time.Time
+	// queue holds the records Record has taken that the writer hasn't
+	// appended yet; wake tells the writer there are some.
+	queue  []LifeRecord
+	wake   chan struct{}
+	done   chan struct{}
+	closed bool
+
+	// Owned by the writer goroutine.
+	file    *os.File
+	pending int // records appended since the last compaction
+}
+
+// OpenLeaderboard loads the store at path, creating it if needed, and
+// starts its writer. An empty path keeps the leaderboard in memory only.
+func OpenLeaderboard(path string) (*Leaderboard, error) {
+	lb := &Leaderboard{path: path, now: time.Now}
+	if path == "" {
+		return lb, nil
+	}
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var r LifeRecord
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("leaderboard %s: %w", path, err)
+			}
+			lb.add(r)
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("leaderboard %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := lb.compact(); err != nil {
+		// The file as loaded is still good; keep appending to it and
+		// try again at the next compaction.
+		log.Printf("Leaderboard compaction: %v", err)
+		if lb.file == nil {
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+			if err != nil {
+				return nil, err
+			}
+			lb.file = f
+		}
+	}
+	lb.wake = make(chan struct{}, 1)
+	lb.done = make(chan struct{})
+	go lb.write()
+	return lb, nil
+}
+
+// Close stops the writer once it has appended every record, and closes
+// the backing file.
+func (lb *Leaderboard) Close() error {
+	if lb == nil {
+		return nil
+	}
+	lb.mu.Lock()
+	if lb.wake == nil || lb.closed {
+		lb.mu.Unlock()
+		return nil
+	}
+	lb.closed = true
+	close(lb.wake)
+	lb.mu.Unlock()
+	<-lb.done
+	if lb.file == nil {
+		return nil
+	}
+	err := lb.file.Close()
+	lb.file = nil
+	return err
+}
+
+func (lb *Leaderboard) add(r LifeRecord) {
+	if r.Bot {
+		lb.bots = append(lb.bots, r)
+	} else {
+		lb.humans = append(lb.humans, r)
+	}
+}
+
+// Record stores a finished life and queues it for the writer. Write
+// errors are logged, not returned: a full disk shouldn't stop the game.
+func (lb *Leaderboard) Record(r LifeRecord) {
+	if lb == nil {
+		return
+	}
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if r.At.IsZero() {
+		r.At = lb.now()
+	}
+	lb.add(r)
+	if lb.wake == nil || lb.closed {
+		return
+	}
+	lb.queue = append(lb.queue, r)
+	select {
+	case lb.wake <- struct{}{}:
+	default:
+	}
+}
+
+// write appends queued records to the file and compacts it once enough
+// have piled up, until Close.
+func (lb *Leaderboard) write() {
+	defer close(lb.done)
+	for range lb.wake {
+		lb.appendQueued()
+	}
+	lb.appendQueued()
+}
+
+func (lb *Leaderboard) appendQueued() {
+	lb.mu.Lock()
+	batch := lb.queue
+	lb.queue = nil
+	lb.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	if lb.file != nil {
+		w := bufio.NewWriter(lb.file)
+		enc := json.NewEncoder(w)
+		for _, r := range batch {
+			enc.Encode(r)
+		}
+		if err := w.Flush(); err != nil {
+			log.Printf("Leaderboard write: %v", err)
+		}
+	}
+	lb.pending += len(batch)
+	if lb.pending >= 4*LeaderboardSize {
+		if err := lb.compact(); err != nil {
+			log.Printf("Leaderboard compaction: %v", err)
+		}
+	}
+}
+
+// Top returns the n best lives, best first, from the bot or human table.
+// A room other than "" limits it to that room, and today to lives that
+// ended since midnight UTC.
+func (lb *Leaderboard) Top(n int, room string, today, bots bool) []LifeRecord {
+	if lb == nil {
+		return nil
+	}
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	table := lb.humans
+	if bots {
+		table = lb.bots
+	}
+	midnight := lb.now().UTC().Truncate(24 * time.Hour)
+	var out []LifeRecord
+	for _, r := range table {
+		if room != "" && r.Room != room {
+			continue
+		}
+		if today && r.At.Before(midnight) {
+			continue
+		}
+		out = append(out, r)
+	}
+	sortLives(out)
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// sortLives orders best first: higher score, then longer, then earlier.
+func sortLives(rs []LifeRecord) {
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].Score != rs[j].Score {
+			return rs[i].Score > rs[j].Score
+		}
+		if rs[i].Length != rs[j].Length {
+			return rs[i].Length > rs[j].Length
+		}
+		return rs[i].At.Before(rs[j].At)
+	})
+}
+
+// compact drops the records no list can show any more and rewrites the
+// file with the rest. It prunes the tables under mu and writes the
+// snapshot outside it; the records queued by then are in the snapshot, so
+// they leave the queue once it has replaced the file. Runs on the writer
+// goroutine, or before it starts.
+func (lb *Leaderboard) compact() error {
+	lb.mu.Lock()
+	midnight := lb.now().UTC().Truncate(24 * time.Hour)
+	prune := func(table []LifeRecord) []LifeRecord {
+		sortLives(table)
+		seen := map[string]int{}
+		var kept []LifeRecord
+		for _, r := range table {
+			groups := []string{"all", "room:" + r.Room}
+			if !r.At.Before(midnight) {
+				groups = append(groups, "today", "today:"+r.Room)
+			}
+			keep := false
+			for _, g := range groups {
+				if seen[g] < LeaderboardSize {
+					seen[g]++
+					keep = true
+				}
+			}
+			if keep {
+				kept = append(kept, r)
+			}
+		}
+		return kept
+	}
+	lb.humans = prune(lb.humans)
+	lb.bots = prune(lb.bots)
+	snapshot := append(append([]LifeRecord(nil), lb.humans...), lb.bots...)
+	covered := len(lb.queue)
+	lb.mu.Unlock()
+	lb.pending = 0
+	if lb.path == "" {
+		return nil
+	}
+
+	if lb.file != nil {
+		lb.file.Close()
+		lb.file = nil
+	}
+	tmp := lb.path + ".tmp"
+	err := func() error {
+		f, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(f)
+		enc := json.NewEncoder(w)
+		for _, r := range snapshot {
+			if err := enc.Encode(r); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		return os.Rename(tmp, lb.path)
+	}()
+	// Whatever happened, keep appending to the file at path.
+	f, openErr := os.OpenFile(lb.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if openErr == nil {
+		lb.file = f
+	}
+	if err != nil {
+		return err
+	}
+	lb.mu.Lock()
+	lb.queue = lb.queue[covered:]
+	lb.mu.Unlock()
+	return openErr
+}
+
+// leaderboard is what lobby playfields record into; see SetLeaderboard.
+var leaderboard *Leaderboard
+
+// SetLeaderboard makes playfields created from now on record finished
+// lives into lb and serves it from LeaderboardHandler.
+func SetLeaderboard(lb *Leaderboard) {
+	lobby.mu.Lock()
+	leaderboard = lb
+	lobby.mu.Unlock()
+}
+
+// leaderboardPacket carries the human top lists a joining player sees:
+// all-time, today and this room.
+func (p *Playfield) leaderboardPacket() Packet {
+	return Packet{Command: "LEADERBOARD", Payload: LeaderboardPayload{
+		AllTime: p.Leaderboard.Top(leaderboardPacketSize, "", false, false),
+		Today:   p.Leaderboard.Top(leaderboardPacketSize, "", true, false),
+		Room:    p.Leaderboard.Top(leaderboardPacketSize, p.Room, false, false),
+	}}
+}
+
+// LeaderboardHandler serves top lists as JSON. Query parameters:
+// n (default 10, at most LeaderboardSize), room, period ("all" or "day")
+// and bots=1 for the bot table.
+func LeaderboardHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		n := 10
+		if v := q.Get("n"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 {
+				http.Error(w, "n must be a positive number", http.StatusBadRequest)
+				return
+			}
+			n = min(parsed, LeaderboardSize)
+		}
+		today := false
+		switch q.Get("period") {
+		case "", "all":
+		case "day":
+			today = true
+		default:
+			http.Error(w, `period must be "all" or "day"`, http.StatusBadRequest)
+			return
+		}
+		lobby.mu.Lock()
+		lb := leaderboard
+		lobby.mu.Unlock()
+		top := lb.Top(n, q.Get("room"), today, q.Get("bots") == "1")
+		if top == nil {
+			top = []LifeRecord{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(top)
+	})
+}
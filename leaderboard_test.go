@@ -0,0 +1,136 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLeaderboardListsAndTables(t *testing.T) {
+	lb, _ := OpenLeaderboard("")
+	now := time.Date(2026, 5, 2, 12, 0, 0, 0, time.UTC)
+	lb.now = func() time.Time { return now }
+
+	lb.Record(LifeRecord{Name: "old", Room: "a", Score: 90, At: now.Add(-24 * time.Hour)})
+	lb.Record(LifeRecord{Name: "new", Room: "b", Score: 50})
+	lb.Record(LifeRecord{Name: "bot", Room: "a", Score: 500, Bot: true})
+
+	if top := lb.Top(10, "", false, false); len(top) != 2 || top[0].Name != "old" {
+		t.Errorf("All-time should be old then new, got %+v", top)
+	}
+	if top := lb.Top(10, "", true, false); len(top) != 1 || top[0].Name != "new" {
+		t.Errorf("Today should only hold new, got %+v", top)
+	}
+	if top := lb.Top(10, "b", false, false); len(top) != 1 || top[0].Name != "new" {
+		t.Errorf("Room b should only hold new, got %+v", top)
+	}
+	if top := lb.Top(10, "", false, true); len(top) != 1 || top[0].Name != "bot" {
+		t.Errorf("Bots should have a table of their own, got %+v", top)
+	}
+	if top := lb.Top(1, "", false, false); len(top) != 1 {
+		t.Errorf("Expected n to cap the list, got %d", len(top))
+	}
+}
+
+func TestLeaderboardPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "board.jsonl")
+	lb, err := OpenLeaderboard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb.Record(LifeRecord{Name: "ada", Room: "1", Score: 70, Length: 9, Cause: CausePacMan})
+	lb.Close()
+
+	lb, err = OpenLeaderboard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+	top := lb.Top(10, "", false, false)
+	if len(top) != 1 || top[0].Name != "ada" || top[0].Length != 9 || top[0].Cause != CausePacMan {
+		t.Errorf("Expected ada's life to survive a restart, got %+v", top)
+	}
+}
+
+func TestLeaderboardCompactionKeepsTheBest(t *testing.T) {
+	lb, _ := OpenLeaderboard(filepath.Join(t.TempDir(), "board.jsonl"))
+	for i := 0; i < 5*LeaderboardSize; i++ {
+		lb.Record(LifeRecord{Name: "p", Room: "1", Score: i})
+	}
+	lb.Close() // waits for the writer
+	if n := len(lb.humans); n > 4*LeaderboardSize {
+		t.Errorf("Expected compaction to bound the table, it holds %d", n)
+	}
+	if top := lb.Top(1, "", false, false); top[0].Score != 5*LeaderboardSize-1 {
+		t.Errorf("Compaction dropped the best life, top is %+v", top[0])
+	}
+}
+
+func TestLeaderboardOpensPastAStaleTmp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "board.jsonl")
+	// Something compaction can't replace where its temporary file goes.
+	if err := os.Mkdir(path+".tmp", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path+".tmp", "x"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lb, err := OpenLeaderboard(path)
+	if err != nil {
+		t.Fatalf("Expected a failed compaction not to stop startup: %v", err)
+	}
+	lb.Record(LifeRecord{Name: "ada", Room: "1", Score: 70})
+	lb.Close()
+
+	lb, err = OpenLeaderboard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+	if top := lb.Top(10, "", false, false); len(top) != 1 || top[0].Name != "ada" {
+		t.Errorf("Expected ada appended despite the failed compaction, got %+v", top)
+	}
+}
+
+func TestDeathIsRecordedOnLeaderboard(t *testing.T) {
+	p := NewPlayfield()
+	p.Room = "lounge"
+	p.Leaderboard, _ = OpenLeaderboard("")
+	w := NewWorm()
+	w.Name = "ada"
+	w.Score = 40
+	p.addMovable(w)
+	w.die(CauseSelf, "Ate yourself", nil)
+	p.announceDeath(w)
+
+	top := p.Leaderboard.Top(10, "lounge", false, false)
+	if len(top) != 1 || top[0].Score != 40 || top[0].Cause != CauseSelf || top[0].Length != len(w.blocks) {
+		t.Errorf("Expected ada's life on the room board, got %+v", top)
+	}
+	if pkt := p.leaderboardPacket(); len(pkt.Payload.(LeaderboardPayload).Room) != 1 {
+		t.Errorf("Expected the LEADERBOARD packet to carry the room list")
+	}
+}
+
+func TestLeaderboardHandler(t *testing.T) {
+	lb, _ := OpenLeaderboard("")
+	lb.Record(LifeRecord{Name: "ada", Room: "1", Score: 10})
+	SetLeaderboard(lb)
+	defer SetLeaderboard(nil)
+
+	rec := httptest.NewRecorder()
+	LeaderboardHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/leaderboard?period=day&room=1", nil))
+	var top []LifeRecord
+	if err := json.Unmarshal(rec.Body.Bytes(), &top); err != nil || len(top) != 1 {
+		t.Errorf("Expected ada in the JSON list, got %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	LeaderboardHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/leaderboard?period=week", nil))
+	if rec.Code != 400 {
+		t.Errorf("Expected an unknown period to be refused, got %d", rec.Code)
+	}
+}
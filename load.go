@@ -0,0 +1,78 @@
+package flow
+
+import (
+	"log"
+	"time"
+)
+
+// Load shedding. The playfield goroutine does a tick's work — delivering
+// the previous tick's broadcasts, then tick itself — inline, so a field
+// that can't keep up just slows down: time.Ticker drops the ticks it can't
+// deliver. Start measures each step against tickBudget percent of
+// the room's Rules.Tick and counts the
+// ticks the Ticker skipped; once overloaded for shedAfter ticks in a row
+// it raises the shed level, and after recoverAfter healthy ticks in a row
+// it lowers it again.
+//
+// Each shed level takes one bot off the room's roster (see aiTargetCount).
+// From spectatorShedLevel on, spectators — humans dead on the game-over
+// screen or disconnected — only get every other tick's MOVEs.
+const (
+	tickBudget          = 80
+	shedAfter           = 10 // 2s at the default Tick
+	recoverAfter        = 50 // 10s
+	MaxShedLevel        = 3
+	spectatorShedLevel  = 2
+	spectatorMoveStride = 2
+)
+
+// tickStarted counts the ticks the Ticker dropped since the last one began
+// and notes when this one did.
+func (p *Playfield) tickStarted(now time.Time) int {
+	skipped := 0
+	if !p.lastTickAt.IsZero() {
+		skipped = max(int(now.Sub(p.lastTickAt)/p.tickLength())-1, 0)
+	}
+	p.lastTickAt = now
+	return skipped
+}
+
+// adjustLoad feeds a finished tick's time and the ticks skipped before it
+// into the shed level.
+func (p *Playfield) adjustLoad(busy time.Duration, skipped int) {
+	if busy > p.tickLength()*tickBudget/100 || skipped > 0 {
+		p.overloadedTicks++
+		p.healthyTicks = 0
+	} else {
+		p.healthyTicks++
+		p.overloadedTicks = 0
+	}
+	switch {
+	case p.overloadedTicks >= shedAfter && p.shedLevel < MaxShedLevel:
+		p.overloadedTicks = 0
+		p.setShedLevel(p.shedLevel+1, busy)
+	case p.healthyTicks >= recoverAfter && p.shedLevel > 0:
+		p.healthyTicks = 0
+		p.setShedLevel(p.shedLevel-1, busy)
+	}
+}
+
+func (p *Playfield) setShedLevel(level int, busy time.Duration) {
+	if level > p.shedLevel {
+		log.Printf("Playfield %s overloaded (last tick took %v of %dms): shedding load, level %d", p.Room, busy.Round(time.Microsecond), p.Rules.Tick, level)
+	} else {
+		log.Printf("Playfield %s keeping up again: shed level %d", p.Room, level)
+	}
+	p.shedLevel = level
+	p.metrics.mu.Lock()
+	p.metrics.shedLevel = level
+	p.metrics.mu.Unlock()
+	p.reconcilePopulation()
+}
+
+// spectatorSkips reports whether deliver holds packet back from w to
+// save work under load.
+func (p *Playfield) spectatorSkips(w *Worm, packet Packet) bool {
+	return p.shedLevel >= spectatorShedLevel && packet.Command == "MOVE" &&
+		p.ticks%spectatorMoveStride != 0 && !w.AI && (w.killed || !w.connected)
+}
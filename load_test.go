@@ -0,0 +1,86 @@
+package flow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTickStartedCountsSkippedTicks(t *testing.T) {
+	p := NewPlayfield()
+	start := time.Now()
+	if n := p.tickStarted(start); n != 0 {
+		t.Errorf("Expected nothing skipped on the first tick, got %d", n)
+	}
+	if n := p.tickStarted(start.Add(Tick * time.Millisecond)); n != 0 {
+		t.Errorf("Expected nothing skipped on time, got %d", n)
+	}
+	if n := p.tickStarted(start.Add(4 * Tick * time.Millisecond)); n != 2 {
+		t.Errorf("Expected two ticks skipped, got %d", n)
+	}
+}
+
+func TestSustainedOverloadShedsBots(t *testing.T) {
+	p := NewPlayfield()
+	w := addWormAt(p, Position{X: 10, Y: 10})
+	w.connected = true
+	p.reconcilePopulation()
+	bots := func() int {
+		n := 0
+		for m := range p.Movables {
+			if ow, ok := m.(*Worm); ok && ow.AI {
+				n++
+			}
+		}
+		return n
+	}
+	if bots() != MinPlayers-1 {
+		t.Fatalf("Expected %d bots to start with, got %d", MinPlayers-1, bots())
+	}
+
+	slow := 2 * Tick * time.Millisecond
+	for i := 0; i < shedAfter-1; i++ {
+		p.adjustLoad(slow, 0)
+	}
+	p.adjustLoad(time.Millisecond, 0)
+	if p.shedLevel != 0 {
+		t.Fatal("Expected a healthy tick to break the overload streak")
+	}
+	for i := 0; i < shedAfter; i++ {
+		p.adjustLoad(time.Millisecond, 1)
+	}
+	if p.shedLevel != 1 || bots() != MinPlayers-2 {
+		t.Errorf("Expected level 1 and a bot shed, got level %d with %d bots", p.shedLevel, bots())
+	}
+	for i := 0; i < recoverAfter; i++ {
+		p.adjustLoad(time.Millisecond, 0)
+	}
+	if p.shedLevel != 0 || bots() != MinPlayers-1 {
+		t.Errorf("Expected recovery to level 0 with the bot back, got level %d with %d bots", p.shedLevel, bots())
+	}
+}
+
+func TestSpectatorsGetFewerMovesUnderLoad(t *testing.T) {
+	p := NewPlayfield()
+	alive := addWormAt(p, Position{X: 10, Y: 10})
+	alive.connected = true
+	dead := addWormAt(p, Position{X: 10, Y: 20})
+	dead.connected = true
+	dead.die(CauseCrash, "Crashed", nil)
+	move := Packet{Command: "MOVE"}
+	p.ticks = 1
+
+	if p.spectatorSkips(dead, move) {
+		t.Error("Expected nothing held back without load")
+	}
+	p.shedLevel = spectatorShedLevel
+	if !p.spectatorSkips(dead, move) {
+		t.Error("Expected a spectator's MOVE held back under load")
+	}
+	if p.spectatorSkips(alive, move) || p.spectatorSkips(dead, Packet{Command: "SCORE"}) {
+		t.Error("Expected players, and packets other than MOVE, untouched")
+	}
+	p.ticks = 2
+	if p.spectatorSkips(dead, move) {
+		t.Error("Expected spectators to still get every other tick's MOVE")
+	}
+}
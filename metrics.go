@@ -0,0 +1,223 @@
+package flow
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tickBuckets are the upper bounds, in seconds, of the tick duration
+// histogram. Tick is 0.2s by default; anything past the room's is an
+// overrun.
+var tickBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.2, 0.5, 1}
+
+// playfieldMetrics is what /metrics reports about one playfield. The
+// playfield goroutine writes it; MetricsHandler reads it under mu.
+type playfieldMetrics struct {
+	mu sync.Mutex
+
+	tickCounts   []int // per tickBuckets entry, not cumulative
+	tickSum      float64
+	tickCount    int
+	tickOverruns int
+	tickSkipped  int
+	shedLevel    int
+	dropped      int
+
+	humans, bots, foods int
+	deaths              map[DeathCause]int
+}
+
+// observeTick records how long a tick's work took, how many ticks the
+// Ticker skipped before it, and what the field held after it.
+func (p *Playfield) observeTick(d time.Duration, skipped int) {
+	humans, bots := 0, 0
+	for m := range p.Movables {
+		if w, ok := m.(*Worm); ok {
+			if isHuman(w) {
+				humans++
+			} else {
+				bots++
+			}
+		}
+	}
+	m := &p.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tickCounts == nil {
+		m.tickCounts = make([]int, len(tickBuckets))
+	}
+	secs := d.Seconds()
+	for i, le := range tickBuckets {
+		if secs <= le {
+			m.tickCounts[i]++
+			break
+		}
+	}
+	m.tickSum += secs
+	m.tickCount++
+	if d > p.tickLength() {
+		m.tickOverruns++
+	}
+	m.tickSkipped += skipped
+	m.humans, m.bots, m.foods = humans, bots, len(p.Foods)
+}
+
+func (p *Playfield) countDrop() {
+	p.metrics.mu.Lock()
+	p.metrics.dropped++
+	p.metrics.mu.Unlock()
+}
+
+func (p *Playfield) countDeath(cause DeathCause) {
+	m := &p.metrics
+	m.mu.Lock()
+	if m.deaths == nil {
+		m.deaths = map[DeathCause]int{}
+	}
+	m.deaths[cause]++
+	m.mu.Unlock()
+}
+
+// MetricsHandler serves /metrics in the Prometheus text format: tick
+// durations, overruns and skips, the load shed level, broadcast queue
+// depth and dropped packets,
+// connection counts and refusals, and each room's population, food and
+// deaths by cause.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetrics(w)
+	})
+}
+
+func writeMetrics(w io.Writer) {
+	lobby.mu.Lock()
+	rooms := make([]string, 0, len(lobby.Playfields))
+	fields := map[string]*Playfield{}
+	for key, p := range lobby.Playfields {
+		rooms = append(rooms, key)
+		fields[key] = p
+	}
+	lobby.mu.Unlock()
+	sort.Strings(rooms)
+
+	// Copy everything out first so no lock is held while writing.
+	type roomMetrics struct {
+		room                string
+		tickCounts          []int
+		tickSum             float64
+		tickCount, overruns int
+		skipped, shedLevel  int
+		depth, dropped      int
+		humans, bots, foods int
+		deaths              map[DeathCause]int
+	}
+	var stats []roomMetrics
+	for _, room := range rooms {
+		p := fields[room]
+		m := &p.metrics
+		m.mu.Lock()
+		rm := roomMetrics{
+			room:       room,
+			tickCounts: append([]int(nil), m.tickCounts...),
+			tickSum:    m.tickSum,
+			tickCount:  m.tickCount,
+			overruns:   m.tickOverruns,
+			skipped:    m.tickSkipped,
+			shedLevel:  m.shedLevel,
+			depth:      len(p.Broadcast),
+			dropped:    m.dropped,
+			humans:     m.humans,
+			bots:       m.bots,
+			foods:      m.foods,
+			deaths:     map[DeathCause]int{},
+		}
+		for c, n := range m.deaths {
+			rm.deaths[c] = n
+		}
+		m.mu.Unlock()
+		stats = append(stats, rm)
+	}
+
+	connCountsMu.Lock()
+	conns, addrs := connTotal, len(connCounts)
+	rejected := map[string]int{}
+	for reason, n := range connRejected {
+		rejected[reason] = n
+	}
+	connCountsMu.Unlock()
+
+	header := func(name, kind, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	perRoom := func(name, kind, help string, value func(roomMetrics) int) {
+		header(name, kind, help)
+		for _, rm := range stats {
+			fmt.Fprintf(w, "%s{room=%s} %d\n", name, labelValue(rm.room), value(rm))
+		}
+	}
+
+	header("flow_tick_duration_seconds", "histogram", "Time spent on one playfield tick, delivering the last tick's broadcasts included.")
+	for _, rm := range stats {
+		room := labelValue(rm.room)
+		cumulative := 0
+		for i, le := range tickBuckets {
+			if i < len(rm.tickCounts) {
+				cumulative += rm.tickCounts[i]
+			}
+			fmt.Fprintf(w, "flow_tick_duration_seconds_bucket{room=%s,le=\"%g\"} %d\n", room, le, cumulative)
+		}
+		fmt.Fprintf(w, "flow_tick_duration_seconds_bucket{room=%s,le=\"+Inf\"} %d\n", room, rm.tickCount)
+		fmt.Fprintf(w, "flow_tick_duration_seconds_sum{room=%s} %g\n", room, rm.tickSum)
+		fmt.Fprintf(w, "flow_tick_duration_seconds_count{room=%s} %d\n", room, rm.tickCount)
+	}
+	perRoom("flow_tick_overruns_total", "counter", "Ticks that took longer than the tick interval.",
+		func(rm roomMetrics) int { return rm.overruns })
+	perRoom("flow_tick_skipped_total", "counter", "Ticks dropped because the playfield fell behind.",
+		func(rm roomMetrics) int { return rm.skipped })
+	perRoom("flow_load_shed_level", "gauge", "How much work the playfield is shedding to keep up; 0 is none.",
+		func(rm roomMetrics) int { return rm.shedLevel })
+	perRoom("flow_broadcast_queue_depth", "gauge", "Packets waiting in the playfield's broadcast queue.",
+		func(rm roomMetrics) int { return rm.depth })
+	perRoom("flow_dropped_packets_total", "counter", "Broadcast packets dropped because a client's outbox was full.",
+		func(rm roomMetrics) int { return rm.dropped })
+	perRoom("flow_room_humans", "gauge", "Human worms in the room, connected or not.",
+		func(rm roomMetrics) int { return rm.humans })
+	perRoom("flow_room_bots", "gauge", "AI and bot API worms in the room.",
+		func(rm roomMetrics) int { return rm.bots })
+	perRoom("flow_room_foods", "gauge", "Food items on the field.",
+		func(rm roomMetrics) int { return rm.foods })
+
+	header("flow_deaths_total", "counter", "Worm deaths by cause.")
+	for _, rm := range stats {
+		causes := make([]string, 0, len(rm.deaths))
+		for c := range rm.deaths {
+			causes = append(causes, string(c))
+		}
+		sort.Strings(causes)
+		for _, c := range causes {
+			fmt.Fprintf(w, "flow_deaths_total{room=%s,cause=%s} %d\n", labelValue(rm.room), labelValue(c), rm.deaths[DeathCause(c)])
+		}
+	}
+
+	header("flow_connections", "gauge", "Open websocket connections.")
+	fmt.Fprintf(w, "flow_connections %d\n", conns)
+	header("flow_connection_addresses", "gauge", "Distinct addresses with an open connection.")
+	fmt.Fprintf(w, "flow_connection_addresses %d\n", addrs)
+	header("flow_connection_rejections_total", "counter", "Connections refused by the connection limits, by reason.")
+	for _, reason := range []string{"per_address", "server_full"} {
+		fmt.Fprintf(w, "flow_connection_rejections_total{reason=%s} %d\n", labelValue(reason), rejected[reason])
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue quotes a label value; room names come from clients.
+func labelValue(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
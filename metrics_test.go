@@ -0,0 +1,75 @@
+package flow
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsReportRooms(t *testing.T) {
+	p := NewPlayfield()
+	addWormAt(p, Position{X: 10, Y: 10})
+	addWormAt(p, Position{X: 10, Y: 20}).Bot = true
+	p.LastFoodId++
+	p.Foods[p.LastFoodId] = &Food{Id: p.LastFoodId, Type: Apple}
+	p.observeTick(time.Millisecond, 0)
+	p.observeTick(300*time.Millisecond, 2)
+	p.countDeath(CausePacMan)
+	p.countDrop()
+	p.Broadcast <- Packet{Command: "FOOD"}
+
+	const room = `metrics "test"`
+	lobby.mu.Lock()
+	lobby.Playfields[room] = p
+	lobby.mu.Unlock()
+	defer func() {
+		lobby.mu.Lock()
+		delete(lobby.Playfields, room)
+		lobby.mu.Unlock()
+	}()
+
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`flow_tick_duration_seconds_bucket{room="metrics \"test\"",le="0.001"} 1`,
+		`flow_tick_duration_seconds_bucket{room="metrics \"test\"",le="0.5"} 2`,
+		`flow_tick_duration_seconds_count{room="metrics \"test\""} 2`,
+		`flow_tick_overruns_total{room="metrics \"test\""} 1`,
+		`flow_tick_skipped_total{room="metrics \"test\""} 2`,
+		`flow_broadcast_queue_depth{room="metrics \"test\""} 1`,
+		`flow_dropped_packets_total{room="metrics \"test\""} 1`,
+		`flow_room_humans{room="metrics \"test\""} 1`,
+		`flow_room_bots{room="metrics \"test\""} 1`,
+		`flow_room_foods{room="metrics \"test\""} 1`,
+		`flow_deaths_total{room="metrics \"test\"",cause="pacman"} 1`,
+		"# TYPE flow_connections gauge",
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("Expected %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsCountRefusedConnections(t *testing.T) {
+	defer func(old int) { perIPConnLimit = old }(perIPConnLimit)
+	perIPConnLimit = 1
+	release, err := addrSlot("192.0.2.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	connCountsMu.Lock()
+	before := connRejected["per_address"]
+	connCountsMu.Unlock()
+	if _, err := addrSlot("192.0.2.1:2"); err == nil {
+		t.Fatal("Expected the second connection refused")
+	}
+	connCountsMu.Lock()
+	after := connRejected["per_address"]
+	connCountsMu.Unlock()
+	if after != before+1 {
+		t.Errorf("Expected one more refusal counted, got %d -> %d", before, after)
+	}
+}
@@ -1,26 +1,103 @@
 package flow
 
-// PacMan is the single hunter that prowls the field while at least one human
-// is online. He occupies a PacManSize × PacManSize footprint anchored at
-// pm.pos (top-left), moves one anchor-cell per tick (same cadence as worms,
-// half the worm's visual speed relative to his own body), wraps the torus,
-// ignores food, and bites segments off any worm whose cell falls inside his
-// footprint. He is not a Movable — the playfield keeps a direct *PacMan
-// pointer and ticks him explicitly, and `occupied()` adds his footprint to
-// the food-spawn block list. Kept separate from worms because he shares
-// nothing with their growth / food / self-collision rules.
+// PacMan is a hunter that prowls the field while at least one human is
+// online; the playfield runs one per HumansPerPacMan humans. He occupies a
+// PacManSize × PacManSize footprint anchored at pm.pos (top-left), moves
+// one anchor-cell per tick (same cadence as worms, half the worm's visual
+// speed relative to his own body), wraps the torus, ignores food, and
+// bites segments off any worm whose cell falls inside his footprint. What
+// he steers toward depends on his PacManKind. He is not a Movable — the
+// playfield keeps a direct []*PacMan and ticks each explicitly, and
+// `occupied()` adds their footprints to the food-spawn block list. Kept
+// separate from worms because he shares nothing with their growth / food /
+// self-collision rules.
 type PacMan struct {
+	Id        Id
 	pos       Position
 	prevPos   Position
 	direction Direction
+	kind      PacManKind
+
+	// waypoint is the patroller's index into pacManPatrolRoute.
+	waypoint int
+	// wanderGoal is the wanderer's current destination; wanderTicks
+	// counts down until he picks a new one even if he never got there.
+	wanderGoal  Position
+	wanderTicks int
+
+	// frightened counts down the ticks left after a worm ate a power
+	// pellet. While non-zero he flees instead of chasing, moves at half
+	// speed, and can be eaten by any worm head that reaches him.
+	frightened int
 }
 
+// PacManKind is a hunter's targeting personality, in the spirit of the
+// arcade ghosts. Clients may use it to tell the hunters apart.
+type PacManKind string
+
+const (
+	// PacManChaser goes straight for the juiciest nearby head.
+	PacManChaser PacManKind = "chaser"
+	// PacManAmbusher cuts off the leader by steering for the cell
+	// PacManAmbushLead steps ahead of its head.
+	PacManAmbusher PacManKind = "ambusher"
+	// PacManPatroller walks a fixed loop around the field and only
+	// breaks off for heads inside PacManPatrolRadius.
+	PacManPatroller PacManKind = "patroller"
+	// PacManWanderer drifts between random cells.
+	PacManWanderer PacManKind = "wanderer"
+)
+
+// pacManKinds is the order personalities are handed out in as the roster
+// grows: the first hunter is always the chaser, matching the single
+// Pac-Man of old.
+var pacManKinds = []PacManKind{PacManChaser, PacManAmbusher, PacManPatroller, PacManWanderer}
+
+const (
+	// PacManAmbushLead is how many cells ahead of the leader's head the
+	// ambusher aims.
+	PacManAmbushLead = 4
+	// PacManPatrolRadius is how close a head must come before the
+	// patroller leaves his route to chase it.
+	PacManPatrolRadius = 8
+	// pacManWanderTicks is how long the wanderer heads for one random
+	// cell before picking another.
+	pacManWanderTicks = 20
+)
+
+// pacManPatrolRoute is the patroller's beat: the centres of the four
+// quadrants, walked clockwise.
+var pacManPatrolRoute = []Position{
+	{Boundary / 4, Boundary / 4},
+	{3 * Boundary / 4, Boundary / 4},
+	{3 * Boundary / 4, 3 * Boundary / 4},
+	{Boundary / 4, 3 * Boundary / 4},
+}
+
+// PacManState tells clients how to render Pac-Man.
+type PacManState string
+
+const (
+	PacManHunting    PacManState = "hunting"
+	PacManFrightened PacManState = "frightened"
+)
+
+const (
+	// PacManFrightenedTicks is how long a power pellet frightens him
+	// (30 ticks = 6s at the default Tick).
+	PacManFrightenedTicks = 30
+	// PacManEatPoints is the bonus for the worm that eats him.
+	PacManEatPoints = 100
+	// PacManRespawnDelay is how many ticks an eaten Pac-Man stays away.
+	PacManRespawnDelay = 25
+)
+
 // PacManSize is the side length (in cells) of Pac-Man's footprint. A 2x2
 // footprint means his mouth visibly engulfs neighbouring worm segments
 // during a bite — the visual matches the mechanic.
 const PacManSize = 2
 
-// NewPacMan spawns a Pac-Man at start, facing Right by default. A real
+// NewPacMan spawns a chaser Pac-Man at start, facing Right by default. A real
 // direction is committed up front (rather than Unknown + lazy-init in a
 // getter) so callers can read pm.direction directly (ai.go's
 // safeDirections and scoreCandidates, plus the inertia bonus in
@@ -30,6 +107,7 @@ func NewPacMan(start Position) *PacMan {
 		pos:       start,
 		prevPos:   start,
 		direction: Right,
+		kind:      PacManChaser,
 	}
 }
 
@@ -57,6 +135,17 @@ func (pm *PacMan) PrevFootprint() []Position { return footprintAt(pm.prevPos) }
 func (pm *PacMan) Position() Position     { return pm.pos }
 func (pm *PacMan) PrevPosition() Position { return pm.prevPos }
 func (pm *PacMan) Direction() Direction   { return pm.direction }
+func (pm *PacMan) Frightened() bool       { return pm.frightened > 0 }
+
+// Frighten starts (or restarts) a frightened window of ticks.
+func (pm *PacMan) Frighten(ticks int) { pm.frightened = ticks }
+
+func (pm *PacMan) State() PacManState {
+	if pm.Frightened() {
+		return PacManFrightened
+	}
+	return PacManHunting
+}
 
 // Move advances Pac-Man one anchor-cell in d, wrapping the torus. Records
 // the previous anchor so the bite phase can compute his prior footprint.
@@ -69,26 +158,51 @@ func (pm *PacMan) Move(d Direction) {
 	pm.pos = wrap(step(pm.pos, d))
 }
 
+// stepPacMan runs Pac-Man's movement for one tick and counts down the
+// frightened window. Frightened, he only moves every other tick so a
+// chasing worm can actually close the gap; on the ticks he holds still
+// prevPos catches up so the swap checks don't see a stale move. His anchor
+// goes through portals the same way a worm head does.
+func stepPacMan(pm *PacMan, p *Playfield) {
+	if pm.frightened%2 == 1 {
+		pm.prevPos = pm.pos
+	} else {
+		pm.Move(pickPacManDirection(pm, p))
+		if exit, ok := p.portalExits[pm.pos]; ok {
+			pm.pos = exit
+		}
+	}
+	if pm.frightened > 0 {
+		pm.frightened--
+	}
+}
+
 // pickPacManDirection scores the four cardinal directions by progress toward
-// the nearest worm head (or, if no head is within hunting range, the nearest
-// worm body cell). Deterministic — Pac-Man is the threat, not a peer; the
-// scariness comes from being predictable in a bad way.
+// the goal his personality picks (see pacManGoal). Apart from the wanderer
+// this is deterministic — Pac-Man is the threat, not a peer; the scariness
+// comes from being predictable in a bad way. Frightened, every kind flips
+// the score's sign and runs from the nearest head instead.
 func pickPacManDirection(pm *PacMan, p *Playfield) Direction {
-	target, ok := pacManTarget(pm, p)
+	target, ok := pacManGoal(pm, p)
+	sign := 1.0
+	if pm.Frightened() {
+		target, ok = pacManThreat(pm, p)
+		sign = -1
+	}
 	if !ok {
 		// No worms to hunt; keep heading.
 		return pm.direction
 	}
 
-	curDist := manhattan(pm.pos, target)
+	curDist := p.distance(pm.pos, target)
 	type scored struct {
 		dir   Direction
 		score float64
 	}
 	best := scored{dir: pm.Direction(), score: -1e9}
 	for _, d := range []Direction{Up, Down, Left, Right} {
-		next := wrap(step(pm.pos, d))
-		s := float64(curDist - manhattan(next, target))
+		next := p.advance(pm.pos, d)
+		s := sign * float64(curDist-p.distance(next, target))
 		if d == pm.direction {
 			s += 0.25 // small inertia: avoid twitching between equally good choices
 		}
@@ -99,6 +213,57 @@ func pickPacManDirection(pm *PacMan, p *Playfield) Direction {
 	return best.dir
 }
 
+// pacManGoal returns the cell pm steers toward this tick according to his
+// kind. ok is false when there is nothing to steer for.
+func pacManGoal(pm *PacMan, p *Playfield) (Position, bool) {
+	switch pm.kind {
+	case PacManAmbusher:
+		return pacManAmbushTarget(pm, p)
+	case PacManPatroller:
+		if head, ok := pacManThreat(pm, p); ok && p.distance(pm.pos, head) <= PacManPatrolRadius {
+			return head, true
+		}
+		if manhattan(pm.pos, pacManPatrolRoute[pm.waypoint]) <= 1 {
+			pm.waypoint = (pm.waypoint + 1) % len(pacManPatrolRoute)
+		}
+		return pacManPatrolRoute[pm.waypoint], true
+	case PacManWanderer:
+		if pm.wanderTicks <= 0 || manhattan(pm.pos, pm.wanderGoal) <= 1 {
+			pm.wanderGoal = p.randomCell()
+			pm.wanderTicks = pacManWanderTicks
+		}
+		pm.wanderTicks--
+		return pm.wanderGoal, true
+	}
+	return pacManTarget(pm, p)
+}
+
+// pacManAmbushTarget aims PacManAmbushLead cells ahead of the leader — the
+// longest living, unprotected worm — so the ambusher lands in its path
+// while the chaser comes from behind. Falls back to chasing when there is
+// no leader with a heading yet.
+func pacManAmbushTarget(pm *PacMan, p *Playfield) (Position, bool) {
+	var leader *Worm
+	for _, m := range p.sortedMovables() {
+		w, ok := m.(*Worm)
+		if !ok || w.killed || w.Protected() || len(w.blocks) == 0 {
+			continue
+		}
+		if leader == nil || len(w.blocks) > len(leader.blocks) ||
+			(len(w.blocks) == len(leader.blocks) && w.Score > leader.Score) {
+			leader = w
+		}
+	}
+	if leader == nil || leader.direction == Unknown {
+		return pacManTarget(pm, p)
+	}
+	ahead := leader.Head()
+	for i := 0; i < PacManAmbushLead; i++ {
+		ahead = p.advance(ahead, leader.direction)
+	}
+	return ahead, true
+}
+
 // pacManTargetHuntRadius caps the manhattan range within which Pac-Man
 // prefers a head over a body. Beyond this, the nearest body cell wins —
 // long worms still have to worry about him picking off a tail even when
@@ -116,19 +281,20 @@ const pacManLengthAttraction = 3
 // Among heads inside pacManTargetHuntRadius the one with the lowest
 // "effective distance" (manhattan minus length × pacManLengthAttraction)
 // wins, so longer worms attract him from farther away. Beyond the head
-// pass he falls back to the nearest body cell.
+// pass he falls back to the nearest body cell. Spawn-protected worms are
+// ignored — he can't bite them, so chasing them would just be camping.
 //
 // Two passes: heads first, body only if nobody's head is in range.
 func pacManTarget(pm *PacMan, p *Playfield) (Position, bool) {
 	var bestHead Position
 	bestHeadEff := 0
 	haveHead := false
-	for m := range p.Movables {
+	for _, m := range p.sortedMovables() {
 		w, ok := m.(*Worm)
-		if !ok || w.killed || len(w.blocks) == 0 {
+		if !ok || w.killed || w.Protected() || len(w.blocks) == 0 {
 			continue
 		}
-		d := manhattan(pm.pos, w.Head())
+		d := p.distance(pm.pos, w.Head())
 		if d > pacManTargetHuntRadius {
 			continue
 		}
@@ -146,13 +312,13 @@ func pacManTarget(pm *PacMan, p *Playfield) (Position, bool) {
 	var bestBody Position
 	bestBodyDist := 0
 	haveBody := false
-	for m := range p.Movables {
+	for _, m := range p.sortedMovables() {
 		w, ok := m.(*Worm)
-		if !ok || w.killed || len(w.blocks) == 0 {
+		if !ok || w.killed || w.Protected() || len(w.blocks) == 0 {
 			continue
 		}
 		for _, b := range w.blocks {
-			db := manhattan(pm.pos, b)
+			db := p.distance(pm.pos, b)
 			if !haveBody || db < bestBodyDist {
 				bestBody = b
 				bestBodyDist = db
@@ -166,6 +332,53 @@ func pacManTarget(pm *PacMan, p *Playfield) (Position, bool) {
 	return Position{}, false
 }
 
+// pacManThreat returns the living worm head nearest to Pac-Man — the one a
+// frightened Pac-Man runs from.
+func pacManThreat(pm *PacMan, p *Playfield) (Position, bool) {
+	var best Position
+	bestDist := 0
+	found := false
+	for _, m := range p.sortedMovables() {
+		w, ok := m.(*Worm)
+		if !ok || w.killed || len(w.blocks) == 0 {
+			continue
+		}
+		if d := p.distance(pm.pos, w.Head()); !found || d < bestDist {
+			best = w.Head()
+			bestDist = d
+			found = true
+		}
+	}
+	return best, found
+}
+
+// resolvePacManEaten is the frightened counterpart of resolvePacManBite:
+// it returns a worm whose head landed in Pac-Man's footprint, or traded
+// cells with him (same swap rule as the bite), or nil if nobody caught him.
+func resolvePacManEaten(pm *PacMan, prevHeads map[*Worm]Position, p *Playfield) *Worm {
+	newFp := posSet(pm.Footprint())
+	prevFp := posSet(pm.PrevFootprint())
+	for _, m := range p.sortedMovables() {
+		w, ok := m.(*Worm)
+		if !ok || w.killed || len(w.blocks) == 0 {
+			continue
+		}
+		if _, hit := newFp[w.Head()]; hit {
+			return w
+		}
+		prev, hadPrev := prevHeads[w]
+		if !hadPrev {
+			continue
+		}
+		_, newHeadInPrev := prevFp[w.Head()]
+		_, oldHeadInNew := newFp[prev]
+		if newHeadInPrev && oldHeadInNew {
+			return w
+		}
+	}
+	return nil
+}
+
 // posSet turns a slice of positions into a set for O(1) membership lookup.
 func posSet(positions []Position) map[Position]struct{} {
 	s := make(map[Position]struct{}, len(positions))
@@ -180,7 +393,7 @@ func posSet(positions []Position) map[Position]struct{} {
 // the worm, body bite truncates blocks[i:] and zeroes growth progress so
 // the worm has to re-earn each lost segment. Returns the worm that was
 // bitten (if any), the segment index of the bite, and the chopped cells
-// for the client puff effect.
+// for the client puff effect. Spawn-protected worms are never bitten.
 //
 // Bite priority — direct-overlap wins globally, not per-worm. Across every
 // living worm we pick the single (worm, segment_index) with the lowest
@@ -207,13 +420,22 @@ func posSet(positions []Position) map[Position]struct{} {
 // truncated to its head, for instance). Kept in for that edge case
 // and for symmetry.
 func resolvePacManBite(pm *PacMan, prevHeads map[*Worm]Position, p *Playfield) (*Worm, int, []Position) {
+	return resolvePacManBiteExcept(pm, prevHeads, p, nil)
+}
+
+// resolvePacManBiteExcept is resolvePacManBite with a set of worms that are
+// off limits — the ones another hunter already bit this tick.
+func resolvePacManBiteExcept(pm *PacMan, prevHeads map[*Worm]Position, p *Playfield, skip map[*Worm]struct{}) (*Worm, int, []Position) {
 	newFp := posSet(pm.Footprint())
 
 	var bestWorm *Worm
 	bestIdx := -1
-	for m := range p.Movables {
+	for _, m := range p.sortedMovables() {
 		w, ok := m.(*Worm)
-		if !ok || w.killed || len(w.blocks) == 0 {
+		if !ok || w.killed || w.Protected() || len(w.blocks) == 0 {
+			continue
+		}
+		if _, off := skip[w]; off {
 			continue
 		}
 		for i, b := range w.blocks {
@@ -234,9 +456,12 @@ func resolvePacManBite(pm *PacMan, prevHeads map[*Worm]Position, p *Playfield) (
 	if bestWorm == nil {
 		// No direct overlap — fall back to head-on swap detection.
 		prevFp := posSet(pm.PrevFootprint())
-		for m := range p.Movables {
+		for _, m := range p.sortedMovables() {
 			w, ok := m.(*Worm)
-			if !ok || w.killed || len(w.blocks) == 0 {
+			if !ok || w.killed || w.Protected() || len(w.blocks) == 0 {
+				continue
+			}
+			if _, off := skip[w]; off {
 				continue
 			}
 			prev, hadPrev := prevHeads[w]
@@ -247,8 +472,7 @@ func resolvePacManBite(pm *PacMan, prevHeads map[*Worm]Position, p *Playfield) (
 			_, oldHeadInNew := newFp[prev]
 			if newHeadInPrev && oldHeadInNew {
 				lost := append([]Position(nil), w.blocks...)
-				w.killed = true
-				w.deathReason = "Eaten by Pac-Man"
+				w.die(CausePacMan, "Eaten by Pac-Man", nil)
 				return w, 0, lost
 			}
 		}
@@ -259,23 +483,8 @@ func resolvePacManBite(pm *PacMan, prevHeads map[*Worm]Position, p *Playfield) (
 	i := bestIdx
 	if i == 0 {
 		lost := append([]Position(nil), w.blocks...)
-		w.killed = true
-		w.deathReason = "Eaten by Pac-Man"
+		w.die(CausePacMan, "Eaten by Pac-Man", nil)
 		return w, 0, lost
 	}
-	lost := append([]Position(nil), w.blocks[i:]...)
-	w.blocks = w.blocks[:i]
-	// Lose growth credit. Score is left alone (the player keeps the
-	// points) but the next growth has to be re-earned from scratch by
-	// scoring another GrowthInterval. Setting lastGrowthScore to the
-	// current score zeroes the partial-credit window — any food
-	// progress toward the next growth is forfeited, which is the right
-	// penalty: a bite that drops N segments should cost N×GrowthInterval
-	// to recover, not less.
-	w.lastGrowthScore = w.Score
-	// In-flight growth from a recent fruit is forfeited too — the worm
-	// just got shorter, queueing more growth would look like the bite
-	// didn't take.
-	w.pendingGrowth = 0
-	return w, i, lost
+	return w, i, w.cut(i)
 }
@@ -287,13 +287,13 @@ func TestReconcilePacManFollowsHumans(t *testing.T) {
 	p.addMovable(w)
 
 	p.reconcilePacMan()
-	if p.pacman == nil {
+	if len(p.pacmen) != 1 {
 		t.Fatal("Pac-Man should spawn while a human is connected")
 	}
 
 	w.connected = false
 	p.reconcilePacMan()
-	if p.pacman != nil {
+	if len(p.pacmen) != 0 {
 		t.Errorf("Pac-Man should despawn when no humans are connected")
 	}
 }
@@ -385,8 +385,9 @@ func TestSafeDirectionsAvoidsPacManFootprint(t *testing.T) {
 	// Right means anchor goes to (22,19) → footprint (22,19),(23,19),
 	// (22,20),(23,20). (20,21) is not in either footprint, so Down is
 	// safe. Up is to (20,19), also outside both footprints.
-	p.pacman = NewPacMan(Position{21, 19})
-	p.pacman.direction = Right
+	pm := NewPacMan(Position{21, 19})
+	pm.direction = Right
+	p.pacmen = []*PacMan{pm}
 
 	dirs := safeDirections(bot, p)
 	for _, d := range dirs {
@@ -395,3 +396,165 @@ func TestSafeDirectionsAvoidsPacManFootprint(t *testing.T) {
 		}
 	}
 }
+
+func TestPacManSkipsProtectedWorm(t *testing.T) {
+	p := NewPlayfield()
+	w := addWormAt(p, Position{30, 30})
+	w.protection = 1
+	pm := placePacManAnchor(Position{w.Head().X - 1, w.Head().Y - 1})
+	prevHeads := map[*Worm]Position{w: w.Head()}
+
+	if bitten, _, _ := resolvePacManBite(pm, prevHeads, p); bitten != nil {
+		t.Errorf("Spawn-protected worm must not be bitten")
+	}
+}
+
+func TestPowerPelletFrightensPacMan(t *testing.T) {
+	p := NewPlayfield()
+	w := NewWorm()
+	p.addMovable(w)
+	pm := NewPacMan(Position{40, 40})
+	p.pacmen = []*PacMan{pm}
+	p.LastFoodId++
+	p.Foods[p.LastFoodId] = &Food{Id: p.LastFoodId, Position: w.Head(), Type: PowerPellet}
+
+	p.resolveFoodCollisions()
+
+	if !pm.Frightened() || pm.State() != PacManFrightened {
+		t.Errorf("Eating a power pellet should frighten Pac-Man")
+	}
+}
+
+// TestFrightenedPacManFlees: with a worm head right of him, a frightened
+// Pac-Man steps away from it despite his Right-facing inertia.
+func TestFrightenedPacManFlees(t *testing.T) {
+	p := NewPlayfield()
+	w := addWormAt(p, Position{30, 20})
+	pm := NewPacMan(Position{25, 20})
+	pm.Frighten(PacManFrightenedTicks)
+
+	d := pickPacManDirection(pm, p)
+	if next := wrap(step(pm.pos, d)); manhattan(next, w.Head()) <= manhattan(pm.pos, w.Head()) {
+		t.Errorf("Frightened Pac-Man should move away from the head, went %v", d)
+	}
+}
+
+func TestFrightenedPacManIsEatenNotBiting(t *testing.T) {
+	p := NewPlayfield()
+	w := addWormAt(p, Position{30, 30})
+	w.connected = true
+	pm := placePacManAnchor(Position{w.Head().X - 1, w.Head().Y - 1})
+	pm.Frighten(PacManFrightenedTicks)
+	p.pacmen = []*PacMan{pm}
+	prevHeads := map[*Worm]Position{w: w.Head()}
+
+	eater := resolvePacManEaten(pm, prevHeads, p)
+	if eater != w {
+		t.Fatalf("Worm head in the footprint should eat a frightened Pac-Man")
+	}
+	p.eatPacMan(pm, eater)
+
+	if w.Score != PacManEatPoints {
+		t.Errorf("Eater should get %d points, got %d", PacManEatPoints, w.Score)
+	}
+	if len(w.blocks) != 4 || w.killed {
+		t.Errorf("Eater should be unharmed")
+	}
+	if len(p.pacmen) != 0 {
+		t.Fatalf("Eaten Pac-Man should leave the field")
+	}
+	p.reconcilePacMan()
+	if len(p.pacmen) != 0 {
+		t.Errorf("Pac-Man should stay away until his respawn delay runs out")
+	}
+}
+
+func TestPacManRosterScalesWithHumans(t *testing.T) {
+	p := NewPlayfield()
+	for i := 0; i < 3; i++ {
+		w := NewWorm()
+		w.connected = true
+		p.addMovable(w)
+	}
+
+	p.reconcilePacMan()
+	if len(p.pacmen) != 2 {
+		t.Fatalf("Three humans should get two Pac-Men, got %d", len(p.pacmen))
+	}
+	if p.pacmen[0].kind == p.pacmen[1].kind {
+		t.Errorf("Hunters should have distinct personalities, both are %q", p.pacmen[0].kind)
+	}
+	if p.pacmen[0].Id == p.pacmen[1].Id {
+		t.Errorf("Hunters should have distinct ids")
+	}
+}
+
+// TestEatenPacMenRespawnOnTheirOwnDelay: eating a second hunter does not
+// push back the first one's return.
+func TestEatenPacMenRespawnOnTheirOwnDelay(t *testing.T) {
+	p := NewPlayfield()
+	for i := 0; i < 3; i++ {
+		addWormAt(p, Position{10, 10 + 5*i}).connected = true
+	}
+	p.reconcilePacMan()
+	if len(p.pacmen) != 2 {
+		t.Fatalf("Three humans should get two Pac-Men, got %d", len(p.pacmen))
+	}
+	eater := NewWorm()
+	p.addMovable(eater)
+
+	p.eatPacMan(p.pacmen[0], eater)
+	for i := 0; i < PacManRespawnDelay/2; i++ {
+		p.tick()
+	}
+	p.eatPacMan(p.pacmen[0], eater)
+	for i := PacManRespawnDelay / 2; i < PacManRespawnDelay; i++ {
+		p.tick()
+	}
+	if len(p.pacmen) != 1 {
+		t.Fatalf("The first Pac-Man eaten should be back on his own delay, got %d hunters", len(p.pacmen))
+	}
+	for i := 0; i < PacManRespawnDelay/2; i++ {
+		p.tick()
+	}
+	if len(p.pacmen) != 2 {
+		t.Errorf("Both Pac-Men should be back, got %d", len(p.pacmen))
+	}
+}
+
+// TestTwoPacMenBiteOnce: once one hunter has bitten a worm this tick, a
+// second hunter overlapping the same worm leaves it alone.
+func TestTwoPacMenBiteOnce(t *testing.T) {
+	p := NewPlayfield()
+	w := addWormAt(p, Position{30, 30})
+	tail := w.blocks[len(w.blocks)-1]
+	first := placePacManAnchor(Position{w.Head().X - 1, w.Head().Y - 1})
+	second := placePacManAnchor(Position{tail.X - 1, tail.Y - 1})
+	prevHeads := map[*Worm]Position{w: w.Head()}
+	bitten := map[*Worm]struct{}{}
+
+	got, _, _ := resolvePacManBiteExcept(first, prevHeads, p, bitten)
+	if got != w {
+		t.Fatalf("First hunter should bite the worm")
+	}
+	bitten[got] = struct{}{}
+	w.killed = false // a head bite kills; revive so only the skip set protects it
+	if again, _, _ := resolvePacManBiteExcept(second, prevHeads, p, bitten); again != nil {
+		t.Errorf("Second hunter must not bite an already-bitten worm")
+	}
+}
+
+func TestAmbusherAimsAheadOfLeader(t *testing.T) {
+	p := NewPlayfield()
+	leader := addWormAt(p, Position{20, 20})
+	leader.blocks = append(leader.blocks, Position{16, 20})
+	addWormAt(p, Position{10, 40})
+	pm := NewPacMan(Position{40, 10})
+	pm.kind = PacManAmbusher
+
+	got, ok := pacManGoal(pm, p)
+	want := Position{20 + PacManAmbushLead, 20}
+	if !ok || got != want {
+		t.Errorf("Ambusher should aim at %v, got %v (ok=%v)", want, got, ok)
+	}
+}
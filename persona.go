@@ -0,0 +1,102 @@
+package flow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+)
+
+// Personas are named, hand-picked or evolved (see Evolve) bot tunings.
+// When a room has some, spawnAI gives each new bot one that isn't on the
+// field yet instead of drawing traits from the difficulty preset; once all
+// are taken it falls back to the preset. A persona plays the same at every
+// difficulty, so adaptive difficulty leaves persona bots alone.
+type Personas []AIPersonality
+
+// LoadPersonas reads a JSON array of AIPersonality objects from path, as
+// written by SavePersonas, and validates it. Seeds in the file are ignored.
+func LoadPersonas(path string) (Personas, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ps Personas
+	if err := json.Unmarshal(raw, &ps); err != nil {
+		return nil, fmt.Errorf("personas %s: %w", path, err)
+	}
+	if err := ps.Validate(); err != nil {
+		return nil, fmt.Errorf("personas %s: %w", path, err)
+	}
+	for i := range ps {
+		ps[i].Seed = 0
+	}
+	return ps, nil
+}
+
+// SavePersonas writes ps to path in the format LoadPersonas reads.
+func SavePersonas(path string, ps Personas) error {
+	raw, err := json.MarshalIndent(ps, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(raw, '\n'), 0o644)
+}
+
+// Validate rejects personas without a unique name (it doubles as the bot's
+// token), negative weights, and rates outside 0..1.
+func (ps Personas) Validate() error {
+	seen := map[string]bool{}
+	for _, p := range ps {
+		if p.Name == "" {
+			return errors.New("persona without a name")
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("duplicate persona %q", p.Name)
+		}
+		seen[p.Name] = true
+		if p.FoodPull < 0 || p.Inertia < 0 || p.CenterPull < 0 || p.PacManFear < 0 {
+			return fmt.Errorf("persona %q: weights must not be negative", p.Name)
+		}
+		if p.HesitationRate < 0 || p.HesitationRate > 1 || p.MistakeRate < 0 || p.MistakeRate > 1 {
+			return fmt.Errorf("persona %q: rates must be between 0 and 1", p.Name)
+		}
+	}
+	return nil
+}
+
+// has reports whether name is one of the personas.
+func (ps Personas) has(name string) bool {
+	for _, p := range ps {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// botToken is the token a bot called name is registered under. Persona
+// names are free-form, so those outside the reserved AI namespace are
+// moved into it: no client can then claim the bot.
+func botToken(name string) string {
+	if isAIToken(name) {
+		return name
+	}
+	return aiTokenPrefix + name
+}
+
+// pick draws a persona that no bot in tokens plays yet, or returns false
+// if every one is taken.
+func (ps Personas) pick(rng *rand.Rand, tokens map[string]*Worm) (AIPersonality, bool) {
+	var free []AIPersonality
+	for _, p := range ps {
+		if _, ok := tokens[botToken(p.Name)]; !ok {
+			free = append(free, p)
+		}
+	}
+	if len(free) == 0 {
+		return AIPersonality{}, false
+	}
+	return free[rng.IntN(len(free))], true
+}
@@ -0,0 +1,152 @@
+package flow
+
+// plannerStrategy is the hard bot. Where classicStrategy greedily closes
+// manhattan distance to a food, the planner:
+//
+//   - drops every safe move whose free region (floodFill) is smaller than
+//     its own body, so it doesn't steer into a pocket it can't turn around
+//     in — unless every move is like that, in which case it takes the
+//     roomiest one and hopes the pocket opens up;
+//   - among the rest, walks the real shortest path (BFS over the torus,
+//     through portals, around bodies and armed bombs' blast areas) to the
+//     nearest edible food.
+//
+// It never hesitates or overlooks a body.
+func plannerStrategy(v *View) Direction {
+	w, p := v.w, v.p
+	candidates := safeDirections(w, p)
+	if len(candidates) == 0 {
+		return w.direction
+	}
+
+	roomy := roomyDirections(w, p, candidates)
+	if len(roomy) == 1 {
+		return roomy[0]
+	}
+	blocked := hazards(w, p)
+
+	// Pathing also steers around blast areas, which are only dangerous
+	// when the fuse runs out, so they don't count against the flood fill.
+	for _, f := range p.Foods {
+		if !f.Armed() {
+			continue
+		}
+		kind, _ := p.Rules.Foods.Kind(f.Type)
+		for _, c := range blastCells(f.Position, kind.BlastRadius) {
+			blocked[c] = struct{}{}
+		}
+	}
+	goals := map[Position]struct{}{}
+	for _, f := range p.Foods {
+		kind, _ := p.Rules.Foods.Kind(f.Type)
+		if kind.Lethal {
+			continue
+		}
+		goals[f.Position] = struct{}{}
+	}
+
+	pick, pickDist := Unknown, -1
+	for _, d := range roomy {
+		dist := pathLength(p, p.advance(w.Head(), d), goals, blocked)
+		if dist < 0 {
+			continue
+		}
+		if pickDist < 0 || dist < pickDist || (dist == pickDist && d == w.direction) {
+			pick, pickDist = d, dist
+		}
+	}
+	if pick == Unknown {
+		// No reachable food: keep heading if that's roomy, else the
+		// first roomy move.
+		for _, d := range roomy {
+			if d == w.direction {
+				return d
+			}
+		}
+		return roomy[0]
+	}
+	return pick
+}
+
+// roomyDirections keeps the candidates whose free region (floodFill) is
+// at least as large as w's body. If none is, it returns just the roomiest
+// one, so a bot already in a pocket still picks the way out most likely
+// to open up.
+func roomyDirections(w *Worm, p *Playfield, candidates []Direction) []Direction {
+	if len(candidates) == 0 {
+		return nil
+	}
+	blocked := hazards(w, p)
+	need := len(w.blocks)
+	var roomy []Direction
+	best, bestRoom := candidates[0], -1
+	for _, d := range candidates {
+		room := floodFill(p, p.advance(w.Head(), d), blocked, need)
+		if room >= need {
+			roomy = append(roomy, d)
+		}
+		if room > bestRoom {
+			best, bestRoom = d, room
+		}
+	}
+	if len(roomy) == 0 {
+		return []Direction{best}
+	}
+	return roomy
+}
+
+// floodFill counts the free cells reachable from start without crossing
+// blocked, stopping once it has seen limit of them. start itself counts.
+func floodFill(p *Playfield, start Position, blocked map[Position]struct{}, limit int) int {
+	if _, hit := blocked[start]; hit {
+		return 0
+	}
+	seen := map[Position]struct{}{start: {}}
+	queue := []Position{start}
+	for len(queue) > 0 && len(seen) < limit {
+		c := queue[0]
+		queue = queue[1:]
+		for _, d := range []Direction{Up, Down, Left, Right} {
+			n := p.advance(c, d)
+			if _, hit := blocked[n]; hit {
+				continue
+			}
+			if _, ok := seen[n]; ok {
+				continue
+			}
+			seen[n] = struct{}{}
+			queue = append(queue, n)
+		}
+	}
+	return len(seen)
+}
+
+// pathLength is the number of moves from start to the nearest goal cell
+// without crossing blocked, or -1 if none can be reached. BFS over the
+// torus, following portals.
+func pathLength(p *Playfield, start Position, goals, blocked map[Position]struct{}) int {
+	if _, hit := blocked[start]; hit {
+		return -1
+	}
+	dist := map[Position]int{start: 0}
+	queue := []Position{start}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if _, ok := goals[c]; ok {
+			return dist[c]
+		}
+		for _, d := range []Direction{Up, Down, Left, Right} {
+			n := p.advance(c, d)
+			if _, hit := blocked[n]; hit {
+				continue
+			}
+			if _, ok := dist[n]; ok {
+				continue
+			}
+			dist[n] = dist[c] + 1
+			queue = append(queue, n)
+		}
+	}
+	return -1
+}
@@ -0,0 +1,53 @@
+package flow
+
+import "testing"
+
+// pocketField puts a bot heading Up at (10,10) next to a two-cell pocket to
+// its right, walled in by another worm, with food inside the pocket.
+func pocketField() (*Playfield, *Worm) {
+	p := NewPlayfield()
+	bot := NewWorm()
+	bot.AI = true
+	bot.personality = AIPersonality{Name: "Test", FoodPull: 2.8}
+	bot.blocks = []Position{{10, 10}, {10, 11}, {10, 12}, {10, 13}, {10, 14}}
+	bot.direction = Up
+	p.addMovable(bot)
+
+	wall := NewWorm()
+	wall.blocks = []Position{{11, 9}, {12, 9}, {13, 9}, {13, 10}, {13, 11}, {12, 11}, {11, 11}, {11, 12}}
+	p.addMovable(wall)
+
+	p.Foods[1] = &Food{Id: 1, Position: Position{12, 10}, Type: Broccoli}
+	return p, bot
+}
+
+func TestFloodFillMeasuresPocket(t *testing.T) {
+	p, bot := pocketField()
+	if got := floodFill(p, Position{11, 10}, hazards(bot, p), 100); got != 2 {
+		t.Errorf("Expected a 2-cell pocket, got %d", got)
+	}
+}
+
+func TestPlannerAvoidsDeadEndPocket(t *testing.T) {
+	p, bot := pocketField()
+	if d := plannerStrategy(&View{w: bot, p: p}); d == Right {
+		t.Errorf("Planner should not enter a pocket shorter than its body")
+	}
+}
+
+func TestPathLengthGoesAroundBodies(t *testing.T) {
+	p := NewPlayfield()
+	bot := NewWorm()
+	bot.blocks = []Position{{10, 10}, {10, 11}, {10, 12}}
+	bot.direction = Up
+	p.addMovable(bot)
+	// A wall straight across the way forces a detour round its left end.
+	wall := NewWorm()
+	wall.blocks = []Position{{10, 8}, {11, 8}, {12, 8}, {13, 8}, {14, 8}, {15, 8}}
+	p.addMovable(wall)
+
+	goals := map[Position]struct{}{{10, 6}: {}}
+	if got := pathLength(p, Position{10, 9}, goals, hazards(bot, p)); got != 5 {
+		t.Errorf("Expected a 5-move detour, got %d", got)
+	}
+}
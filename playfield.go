@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"math/rand/v2"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -13,10 +14,15 @@ func joinNames(names []string) string {
 	return strings.Join(names, " & ")
 }
 
-// How fast playfields switch packets to clients
-const Tick = 200
+// How fast playfields switch packets to clients: the default Rules.Tick,
+// in milliseconds, and the range a room may set it to.
+const (
+	Tick    = 200
+	MinTick = 20
+	MaxTick = 2000
+)
 
-// DisconnectTTL is how long a human worm lingers in the field after its
+// DisconnectTTL is the default for how long a human worm lingers in the field after its
 // websocket drops, before the playfield removes it. Long enough that a
 // browser refresh reconnects to the same snake, short enough that idle
 // snakes don't pile up and stall the broadcast layer.
@@ -27,19 +33,36 @@ type Id uint
 // The lobby takes care of listing all playfields
 type Lobby struct {
 	Playfields map[string]*Playfield
-	mu         sync.Mutex
+	// Rules is what each new playfield starts with.
+	Rules Rules
+	mu    sync.Mutex
 }
 
-var lobby = &Lobby{Playfields: make(map[string]*Playfield)}
+var lobby = &Lobby{Playfields: make(map[string]*Playfield), Rules: DefaultRules()}
+
+// SetRules changes the rules playfields created from now on run with.
+// Playfields that already exist keep theirs.
+func SetRules(r Rules) {
+	lobby.mu.Lock()
+	lobby.Rules = r
+	lobby.mu.Unlock()
+}
 
 func (l *Lobby) Playfield(key string) *Playfield {
 	l.mu.Lock()
 	p, ok := l.Playfields[key]
 	if !ok {
 		p = NewPlayfield()
+		p.Rules = l.Rules
+		p.Room = key
+		p.Leaderboard = leaderboard
+		p.Profiles = profiles
+		p.ReplayDir = replayDir
+		p.ReplayMaxTicks, p.ReplayKeep = replayMaxTicks, replayKeep
+		p.Events = events
 		p.Start()
 		l.Playfields[key] = p
-		log.Printf("New playfield: %s", key)
+		log.Printf("New playfield: %s (seed %d)", key, p.Seed)
 		// AI bots are added/removed by the playfield itself in response to
 		// human population — no pre-spawn here.
 	}
@@ -67,6 +90,7 @@ type RespawnRequest struct {
 type AttachRequest struct {
 	Token string
 	Name  string
+	Bot   bool // connected through the bot API with a valid key
 	Reply chan AttachReply
 }
 
@@ -103,6 +127,7 @@ type Playfield struct {
 	Attach     chan AttachRequest
 	ConnState  chan ConnState
 	MoveCmd    chan DirectionRequest
+	Admin      chan AdminRequest
 	LastId     Id
 	Foods      map[Id]*Food
 	LastFoodId Id
@@ -113,13 +138,80 @@ type Playfield struct {
 	// stale until it reconnects and we resync.
 	Tokens map[string]*Worm
 
-	// pacman is the single hunter on the field while any human is online.
-	// nil otherwise. Kept as a direct pointer so the tick's bite phase can
-	// reach him without iterating Movables.
-	pacman *PacMan
+	// pacmen are the hunters on the field — pacManTargetCount of them
+	// while any human is online, none otherwise. Kept as a direct slice
+	// so the tick's bite phase can reach them without iterating Movables;
+	// slice order is spawn order, which keeps bite resolution stable.
+	pacmen       []*PacMan
+	LastPacManId Id
+	// pacmanRespawns counts down, per eaten Pac-Man, the ticks until he
+	// may be replaced. reconcilePacMan leaves a slot in the roster empty
+	// for each until then.
+	pacmanRespawns []int
+
+	// Rules holds the optional mechanics this playfield runs with.
+	Rules Rules
+
+	// Room is the lobby key the playfield was created under, Leaderboard
+	// where its finished lives are recorded and Profiles where players'
+	// lifetime stats are kept (nil for none).
+	Room        string
+	Leaderboard *Leaderboard
+	Profiles    *Profiles
+
+	// ReplayDir is where the playfield records its broadcasts to while a
+	// round is on (see replay.go); empty for no recording. Each file runs
+	// at most ReplayMaxTicks, and the directory keeps ReplayKeep files.
+	ReplayDir      string
+	ReplayMaxTicks int
+	ReplayKeep     int
+	replay         *replayRecorder
+
+	// Events receives what happens on the field (see events.go); nil for
+	// nowhere. inRound and roundStart track the current round.
+	Events     EventSink
+	inRound    bool
+	roundStart int
+
+	// metrics is what MetricsHandler reports for the playfield.
+	metrics playfieldMetrics
+
+	// Load shedding state; see load.go.
+	lastTickAt      time.Time
+	overloadedTicks int
+	healthyTicks    int
+	shedLevel       int
+
+	// portals are the linked cell pairs on the field, placed once by
+	// Start; portalExits maps each end to its partner and is shared with
+	// every worm so Worm.Move can follow it.
+	portals     []Portal
+	portalExits map[Position]Position
+
+	// adaptedDifficulty is the level adaptDifficulty last settled on;
+	// empty until it first runs, meaning Rules.Difficulty applies.
+	adaptedDifficulty Difficulty
+
+	// ticks counts game-loop steps. Food lifetimes are expressed in ticks
+	// so expiry follows game time rather than wall-clock time.
+	ticks int
+
+	// Seed is what rng was started from. Every random choice the game
+	// makes — spawns, food rolls, bot personas and moves, Pac-Man
+	// wandering — draws from rng, so the same seed fed the same inputs
+	// plays out the same game.
+	Seed uint64
+	rng  *rand.Rand
 }
 
+// NewPlayfield returns a playfield seeded at random; see NewSeededPlayfield.
 func NewPlayfield() *Playfield {
+	return NewSeededPlayfield(rand.Uint64())
+}
+
+// NewSeededPlayfield returns a playfield whose randomness all comes from
+// seed, for replays and reproducible tests.
+func NewSeededPlayfield(seed uint64) *Playfield {
 	return &Playfield{
 		Movables:  make(map[Movable]Id),
 		Ticker:    time.NewTicker(Tick * time.Millisecond),
@@ -131,15 +223,57 @@ func NewPlayfield() *Playfield {
 		Attach:    make(chan AttachRequest, 16),
 		ConnState: make(chan ConnState, 16),
 		MoveCmd:   make(chan DirectionRequest, 32),
+		Admin:     make(chan AdminRequest, 16),
 		LastId:    0,
 		Foods:     make(map[Id]*Food),
 		Tokens:    make(map[string]*Worm),
+		Rules:     DefaultRules(),
+
+		ReplayMaxTicks: ReplayMaxTicks,
+		ReplayKeep:     ReplayKeep,
+
+		portalExits: make(map[Position]Position),
+
+		Seed: seed,
+		rng:  rand.New(rand.NewPCG(seed, seed^0x5DEECE66D)),
 	}
 }
 
+// sortedMovables returns the movables in id order. Anything whose outcome
+// depends on who goes first ranges over this (or sortedFoods) instead of
+// the maps, whose order Go randomises, so a seeded game replays exactly.
+func (p *Playfield) sortedMovables() []Movable {
+	out := make([]Movable, 0, len(p.Movables))
+	for m := range p.Movables {
+		out = append(out, m)
+	}
+	slices.SortFunc(out, func(a, b Movable) int { return int(p.Movables[a]) - int(p.Movables[b]) })
+	return out
+}
+
+// sortedFoods returns the foods in id order; see sortedMovables.
+func (p *Playfield) sortedFoods() []*Food {
+	out := make([]*Food, 0, len(p.Foods))
+	for _, f := range p.Foods {
+		out = append(out, f)
+	}
+	slices.SortFunc(out, func(a, b *Food) int { return int(a.Id) - int(b.Id) })
+	return out
+}
+
+// randomCell is a uniformly random cell on the field.
+func (p *Playfield) randomCell() Position {
+	return Position{X: p.rng.IntN(Boundary + 1), Y: p.rng.IntN(Boundary + 1)}
+}
+
+// randomDirection is a uniformly random heading, for fresh spawns.
+func (p *Playfield) randomDirection() Direction {
+	return []Direction{Up, Down, Left, Right}[p.rng.IntN(4)]
+}
+
 // occupied returns positions currently blocked (worm bodies + existing
-// food + Pac-Man's footprint). Pac-Man isn't a Movable so he has to be
-// added explicitly; without this, food could spawn under him.
+// food + every Pac-Man's footprint). Pac-Men aren't Movables so they have
+// to be added explicitly; without this, food could spawn under them.
 func (p *Playfield) occupied() map[Position]struct{} {
 	out := make(map[Position]struct{})
 	for m := range p.Movables {
@@ -147,48 +281,179 @@ func (p *Playfield) occupied() map[Position]struct{} {
 			out[pos] = struct{}{}
 		}
 	}
-	if p.pacman != nil {
-		for _, c := range p.pacman.Footprint() {
+	for _, pm := range p.pacmen {
+		for _, c := range pm.Footprint() {
 			out[c] = struct{}{}
 		}
 	}
 	for _, f := range p.Foods {
 		out[f.Position] = struct{}{}
 	}
+	for c := range p.portalExits {
+		out[c] = struct{}{}
+	}
 	return out
 }
 
-// MaxActiveBombs caps how many bombs may exist on the field at once. Without
-// this, repeated rolls of `Bomb` would gradually replace every fruit until
-// the player has nothing edible to chase.
-const MaxActiveBombs = 2
-
-// spawnFood adds a new food item to the field. Returns the spawned food
-// so callers can broadcast it (or send privately on initial state delivery).
-// If the random roll would push the bomb count past MaxActiveBombs, the
-// spawn is forced to a fruit instead so the field always has something
-// rewarding to eat.
-func (p *Playfield) spawnFood() Food {
+// spawnFood adds a new food item to the field, rolled from the Rules.Foods
+// catalogue. Returns the spawned food so callers can broadcast it (or send
+// privately on initial state delivery). Kinds already at their MaxActive
+// cap are left out of the roll, so the field always has something
+// rewarding to eat. Returns false, spawning nothing, when every kind is at
+// its cap.
+func (p *Playfield) spawnFood() (Food, bool) {
+	active := map[FoodType]int{}
+	for _, f := range p.Foods {
+		active[f.Type]++
+	}
+	f, kind, ok := randomFood(p.rng, p.LastFoodId+1, p.occupied(), p.Rules.Foods, active)
+	if !ok {
+		return Food{}, false
+	}
 	p.LastFoodId++
-	bombs := 0
+	if kind.Lifetime > 0 {
+		f.expiresAt = p.ticks + kind.Lifetime
+	}
+	p.Foods[f.Id] = &f
+	return f, true
+}
+
+// regularFoodCount is the number of foods that count toward FoodCount —
+// everything except remains.
+func (p *Playfield) regularFoodCount() int {
+	n := 0
 	for _, f := range p.Foods {
-		if f.Type == Bomb {
-			bombs++
+		if f.Type != Remains {
+			n++
 		}
 	}
-	f := randomFood(p.LastFoodId, p.occupied())
-	if f.Type == Bomb && bombs >= MaxActiveBombs {
-		if rand.IntN(2) == 0 {
-			f.Type = Apple
-		} else {
-			f.Type = Carrot
+	return n
+}
+
+// spawnRemains turns every RemainsEvery-th cell of a dead (or bitten-off)
+// body into a Remains food that fades after the catalogue's remains
+// Lifetime. A catalogue without a remains entry leaves nothing behind. Cells
+// already taken by a living worm, Pac-Man or another food are skipped, so
+// the remains never appear under something. Returns the spawned foods for
+// the caller to broadcast.
+func (p *Playfield) spawnRemains(cells []Position) []Food {
+	kind, ok := p.Rules.Foods.Kind(Remains)
+	if !ok {
+		return nil
+	}
+	blocked := map[Position]struct{}{}
+	for m := range p.Movables {
+		w, ok := m.(*Worm)
+		if !ok || w.killed {
+			continue
+		}
+		for _, b := range w.blocks {
+			blocked[b] = struct{}{}
+		}
+	}
+	for _, pm := range p.pacmen {
+		for _, c := range pm.Footprint() {
+			blocked[c] = struct{}{}
+		}
+	}
+	for _, f := range p.Foods {
+		blocked[f.Position] = struct{}{}
+	}
+	for c := range p.portalExits {
+		blocked[c] = struct{}{}
+	}
+
+	var out []Food
+	for i := 0; i < len(cells); i += p.Rules.RemainsEvery {
+		c := cells[i]
+		if _, taken := blocked[c]; taken {
+			continue
+		}
+		blocked[c] = struct{}{}
+		p.LastFoodId++
+		f := Food{Id: p.LastFoodId, Position: c, Type: Remains}
+		if kind.Lifetime > 0 {
+			f.expiresAt = p.ticks + kind.Lifetime
+		}
+		p.Foods[f.Id] = &f
+		out = append(out, f)
+	}
+	return out
+}
+
+// expireFoods drops every food whose lifetime has run out and tells
+// clients with FOOD_EXPIRE. A regular food is replaced elsewhere so the
+// field stays at FoodCount; remains just go.
+func (p *Playfield) expireFoods() {
+	for _, f := range p.sortedFoods() {
+		id := f.Id
+		if f.expiresAt == 0 || p.ticks < f.expiresAt {
+			continue
+		}
+		delete(p.Foods, id)
+		p.Broadcast <- Packet{Command: "FOOD_EXPIRE", Payload: FoodExpirePayload{FoodId: id}}
+		if f.Type != Remains {
+			if nf, ok := p.spawnFood(); ok {
+				p.Broadcast <- p.foodPacket(nf)
+			}
 		}
 	}
-	p.Foods[f.Id] = &f
-	return f
 }
 
-func foodPacket(f Food) Packet {
+// fleeFoods moves each fleeing food (FoodKind.Flee) one cell away from the
+// nearest living worm head, once every Flee ticks. It steps onto the free
+// neighbour that ends up furthest from that head and stays put when no
+// neighbour beats where it is now. Moves go out as FOOD_MOVE.
+func (p *Playfield) fleeFoods() {
+	var heads []Position
+	for _, m := range p.sortedMovables() {
+		if w, ok := m.(*Worm); ok && !w.killed {
+			heads = append(heads, w.Head())
+		}
+	}
+	if len(heads) == 0 {
+		return
+	}
+	var blocked map[Position]struct{}
+	for _, f := range p.sortedFoods() {
+		kind, _ := p.Rules.Foods.Kind(f.Type)
+		if kind.Flee <= 0 || p.ticks%kind.Flee != 0 {
+			continue
+		}
+		if blocked == nil {
+			blocked = p.occupied()
+		}
+		threat := heads[0]
+		for _, h := range heads[1:] {
+			if manhattan(f.Position, h) < manhattan(f.Position, threat) {
+				threat = h
+			}
+		}
+		best, bestDist := f.Position, manhattan(f.Position, threat)
+		for _, d := range []Direction{Up, Down, Left, Right} {
+			next := wrap(step(f.Position, d))
+			if _, taken := blocked[next]; taken {
+				continue
+			}
+			if dist := manhattan(next, threat); dist > bestDist {
+				best, bestDist = next, dist
+			}
+		}
+		if best == f.Position {
+			continue
+		}
+		delete(blocked, f.Position)
+		blocked[best] = struct{}{}
+		f.Position = best
+		p.Broadcast <- Packet{Command: "FOOD_MOVE", Payload: FoodMovePayload{FoodId: f.Id, X: best.X, Y: best.Y}}
+	}
+}
+
+func (p *Playfield) foodPacket(f Food) Packet {
+	ttl := 0
+	if f.expiresAt != 0 {
+		ttl = (f.expiresAt - p.ticks) * p.Rules.Tick
+	}
 	return Packet{
 		Command: "FOOD",
 		Payload: FoodPayload{
@@ -196,7 +461,8 @@ func foodPacket(f Food) Packet {
 			X:      f.Position.X,
 			Y:      f.Position.Y,
 			Type:   f.Type,
-			Points: f.Points(),
+			Points: p.Rules.Foods.Points(f.Type),
+			TTL:    ttl,
 		},
 	}
 }
@@ -204,7 +470,7 @@ func foodPacket(f Food) Packet {
 func scorePacket(id Id, w *Worm) Packet {
 	return Packet{
 		Command: "SCORE",
-		Payload: ScorePayload{WormId: id, Name: w.Name, Score: w.Score},
+		Payload: ScorePayload{WormId: id, Name: w.Name, Score: w.Score, Protected: w.Protected(), Multiplier: w.Multiplier(), Strategy: w.strategy, Bot: w.AI || w.Bot},
 	}
 }
 
@@ -234,10 +500,13 @@ func (p *Playfield) safeSpawn() Position {
 		}
 		heads = append(heads, w.Head())
 	}
+	for c := range p.portalExits {
+		occupied[c] = struct{}{}
+	}
 
 	// First pass: insist on the head-distance buffer.
 	for tries := 0; tries < 200; tries++ {
-		pos := Position{X: rand.IntN(Boundary + 1), Y: rand.IntN(Boundary + 1)}
+		pos := p.randomCell()
 		if _, blocked := occupied[pos]; blocked {
 			continue
 		}
@@ -254,50 +523,64 @@ func (p *Playfield) safeSpawn() Position {
 	}
 	// Fallback: any empty cell at all.
 	for tries := 0; tries < 200; tries++ {
-		pos := Position{X: rand.IntN(Boundary + 1), Y: rand.IntN(Boundary + 1)}
+		pos := p.randomCell()
 		if _, blocked := occupied[pos]; !blocked {
 			return pos
 		}
 	}
 	// Last resort — shouldn't be reachable on a 50×50 field.
-	return Position{X: rand.IntN(Boundary + 1), Y: rand.IntN(Boundary + 1)}
+	return p.randomCell()
 }
 
-// MinPlayers is the total player count (humans + bots) the playfield tops
-// itself up to whenever at least one human is connected. With this set to 4,
-// a lone human gets 3 bot rivals, three humans get 1 bot, and four or more
-// humans get no bots at all.
+// MinPlayers is the default total player count (humans + bots) the
+// playfield tops itself up to whenever at least one human is connected.
+// With this set to 4, a lone human gets 3 bot rivals, three humans get 1
+// bot, and four or more humans get no bots at all.
 const MinPlayers = 4
 
-// aiTargetCount returns how many AI bots the playfield should currently host
-// based on how many human worms are connected. With no humans there are no
-// bots (no point burning ticks). With humans present, top up to MinPlayers
-// so the field always feels populated.
-func (p *Playfield) aiTargetCount() int {
+// AutoBots is the Rules.Bots setting that sizes the bot roster by
+// MinPlayers; MaxBots caps any other setting.
+const (
+	AutoBots = -1
+	MaxBots  = 16
+)
+
+// humanCount returns how many human worms currently have a live websocket.
+// Bot API worms don't count: a room with only them gets no AI bots.
+func (p *Playfield) humanCount() int {
 	humans := 0
 	for m := range p.Movables {
-		if w, ok := m.(*Worm); ok && !w.AI && w.connected {
+		if w, ok := m.(*Worm); ok && isHuman(w) && w.connected {
 			humans++
 		}
 	}
+	return humans
+}
+
+// aiTargetCount returns how many AI bots the playfield should currently host
+// based on how many human worms are connected. With no humans there are no
+// bots (no point burning ticks). With humans present, top up to MinPlayers
+// so the field always feels populated.
+func (p *Playfield) aiTargetCount() int {
+	humans := p.humanCount()
 	if humans == 0 {
 		return 0
 	}
-	target := MinPlayers - humans
-	if target < 0 {
-		return 0
+	target := p.Rules.MinPlayers - humans
+	if p.Rules.Bots != AutoBots {
+		target = p.Rules.Bots
 	}
-	return target
+	// An overloaded field sheds a bot per level (see load.go).
+	return max(target-p.shedLevel, 0)
 }
 
 // reconcilePopulation nudges the AI roster toward aiTargetCount and the
-// Pac-Man hunter toward "present iff a human is online". Called whenever
-// the human roster changes (ConnState / stale-sweep). Bots and Pac-Man
-// share the same lifetime trigger because both are pointless on an empty
-// field.
+// Pac-Man roster toward pacManTargetCount. Called whenever the human roster
+// changes (ConnState / stale-sweep). Bots and Pac-Men share the same
+// lifetime trigger because both are pointless on an empty field.
 func (p *Playfield) reconcilePopulation() {
 	currentAIs := make([]*Worm, 0, 4)
-	for m := range p.Movables {
+	for _, m := range p.sortedMovables() {
 		if w, ok := m.(*Worm); ok && w.AI {
 			currentAIs = append(currentAIs, w)
 		}
@@ -312,22 +595,46 @@ func (p *Playfield) reconcilePopulation() {
 	p.reconcilePacMan()
 }
 
-// reconcilePacMan spawns or despawns the single Pac-Man hunter to match the
-// "any human online" predicate. Pac-Man's presence is tied to humans the
-// same way AI worms are — no point hunting when the field is empty.
-func (p *Playfield) reconcilePacMan() {
-	wantPacMan := false
-	for m := range p.Movables {
-		if w, ok := m.(*Worm); ok && !w.AI && w.connected {
-			wantPacMan = true
-			break
-		}
+// HumansPerPacMan is by default how many connected humans each Pac-Man is
+// there for: one or two humans get a single hunter, three or four get two,
+// and so on up to MaxPacMen.
+const HumansPerPacMan = 2
+
+// MaxPacMen caps the hunter roster by default, and always at one of each
+// PacManKind.
+const MaxPacMen = 4
+
+// pacManTargetCount returns how many Pac-Men the playfield should host for
+// the current human count. Zero with nobody online — no point hunting an
+// empty field — or with Rules.NoPacMan.
+func (p *Playfield) pacManTargetCount() int {
+	if p.Rules.NoPacMan {
+		return 0
+	}
+	n := (p.humanCount() + p.Rules.HumansPerPacMan - 1) / p.Rules.HumansPerPacMan
+	if n > p.Rules.MaxPacMen {
+		n = p.Rules.MaxPacMen
 	}
-	if wantPacMan && p.pacman == nil {
+	return n
+}
+
+// reconcilePacMan spawns or despawns hunters until the roster matches
+// pacManTargetCount. Each eaten Pac-Man whose respawn delay is still
+// running keeps one slot empty; trimming always happens immediately,
+// newest hunter first, and drops waiting respawns the roster no longer
+// has room for.
+func (p *Playfield) reconcilePacMan() {
+	target := p.pacManTargetCount()
+	for len(p.pacmen)+len(p.pacmanRespawns) < target {
 		p.spawnPacMan()
-	} else if !wantPacMan && p.pacman != nil {
-		p.Broadcast <- Packet{Command: "PACMAN_KILL"}
-		p.pacman = nil
+	}
+	for len(p.pacmen) > target {
+		pm := p.pacmen[len(p.pacmen)-1]
+		p.pacmen = p.pacmen[:len(p.pacmen)-1]
+		p.Broadcast <- Packet{Command: "PACMAN_KILL", Payload: PacManKillPayload{PacManId: pm.Id}}
+	}
+	if room := target - len(p.pacmen); len(p.pacmanRespawns) > room {
+		p.pacmanRespawns = p.pacmanRespawns[:room]
 	}
 }
 
@@ -335,12 +642,46 @@ func (p *Playfield) reconcilePacMan() {
 // footprint clear of worm bodies, and far enough from every worm head
 // that no worm gets a first-tick freebie bite) and broadcasts the
 // initial PACMAN packet so clients render him before the next tick.
+// He takes the first PacManKind nobody on the field has yet, so a
+// roster of N hunters always has N different personalities.
 func (p *Playfield) spawnPacMan() {
 	pm := NewPacMan(p.safePacManAnchor())
-	p.pacman = pm
+	p.LastPacManId++
+	pm.Id = p.LastPacManId
+	inUse := map[PacManKind]bool{}
+	for _, other := range p.pacmen {
+		inUse[other.kind] = true
+	}
+	for _, k := range pacManKinds {
+		if !inUse[k] {
+			pm.kind = k
+			break
+		}
+	}
+	p.pacmen = append(p.pacmen, pm)
 	p.Broadcast <- pacManPacket(pm)
 }
 
+// eatPacMan credits w with PacManEatPoints and takes pm off the field.
+// reconcilePacMan replaces him after PacManRespawnDelay ticks through the
+// usual safePacManAnchor spawn.
+func (p *Playfield) eatPacMan(pm *PacMan, w *Worm) {
+	for i, other := range p.pacmen {
+		if other == pm {
+			p.pacmen = append(p.pacmen[:i], p.pacmen[i+1:]...)
+			break
+		}
+	}
+	p.pacmanRespawns = append(p.pacmanRespawns, p.Rules.PacManRespawnDelay)
+	id := p.Movables[w]
+	p.Broadcast <- Packet{Command: "PACMAN_KILL", Payload: PacManKillPayload{PacManId: pm.Id, WormId: id}}
+	w.AddScore(p.Rules.PacManEatPoints)
+	e := p.wormEvent(EventEat, w)
+	e.PacManId, e.Points = pm.Id, p.Rules.PacManEatPoints
+	p.emit(e)
+	p.Broadcast <- scorePacket(id, w)
+}
+
 // safePacManAnchor returns an anchor cell whose 2x2 footprint is clear of
 // every worm body and at least minHeadDistance manhattan-cells from each
 // worm head. Mirrors safeSpawn (which is single-cell), but extended to
@@ -361,6 +702,13 @@ func (p *Playfield) safePacManAnchor() Position {
 		}
 		heads = append(heads, w.Head())
 	}
+	// Hunters already on the field count as bodies so two of them never
+	// spawn stacked on each other.
+	for _, pm := range p.pacmen {
+		for _, c := range pm.Footprint() {
+			bodies[c] = struct{}{}
+		}
+	}
 
 	footprintClear := func(anchor Position) bool {
 		for _, c := range footprintAt(anchor) {
@@ -377,7 +725,7 @@ func (p *Playfield) safePacManAnchor() Position {
 	}
 
 	for tries := 0; tries < 200; tries++ {
-		anchor := Position{X: rand.IntN(Boundary + 1), Y: rand.IntN(Boundary + 1)}
+		anchor := p.randomCell()
 		if footprintClear(anchor) {
 			return anchor
 		}
@@ -385,7 +733,7 @@ func (p *Playfield) safePacManAnchor() Position {
 	// Fallback: drop the head-distance constraint but still avoid body
 	// overlap, so the spawn at least doesn't start mid-worm.
 	for tries := 0; tries < 200; tries++ {
-		anchor := Position{X: rand.IntN(Boundary + 1), Y: rand.IntN(Boundary + 1)}
+		anchor := p.randomCell()
 		ok := true
 		for _, c := range footprintAt(anchor) {
 			if _, hit := bodies[c]; hit {
@@ -429,29 +777,45 @@ func pacManPacket(pm *PacMan) Packet {
 	return Packet{
 		Command: "PACMAN",
 		Payload: PacManPayload{
+			Id:        pm.Id,
+			Kind:      pm.kind,
 			X:         pm.pos.X,
 			Y:         pm.pos.Y,
 			Direction: pm.Direction().String(),
+			State:     pm.State(),
 		},
 	}
 }
 
-// spawnAI inserts a fresh AI bot into the playfield with a unique
-// random-seeded persona and a randomized starting cell.
+// spawnAI inserts a fresh AI bot into the playfield with a unique persona
+// — one of Rules.Personas if any is free, else random-seeded — and a
+// randomized starting cell.
 func (p *Playfield) spawnAI() {
-	personality := newPersonality()
-	// Avoid colliding with an existing token (extremely unlikely, but safe).
-	for _, exists := p.Tokens[personality.Name]; exists; _, exists = p.Tokens[personality.Name] {
-		personality = newPersonality()
+	personality, ok := p.Rules.Personas.pick(p.rng, p.Tokens)
+	if !ok {
+		personality = newPersonality(p.rng, p.difficulty())
+		// Avoid colliding with an existing token (extremely unlikely, but safe).
+		for _, exists := p.Tokens[personality.Name]; exists; _, exists = p.Tokens[personality.Name] {
+			personality = newPersonality(p.rng, p.difficulty())
+		}
+	}
+	running := map[string]int{}
+	for m := range p.Movables {
+		if ow, ok := m.(*Worm); ok && ow.AI {
+			running[ow.strategy]++
+		}
 	}
 	w := NewWorm()
 	w.AI = true
 	w.personality = personality
+	w.strategy = p.Rules.BotMix.pick(running)
 	w.Name = personality.Name
-	w.Token = personality.Name
+	w.Token = botToken(personality.Name)
 	placeAt(w, p.safeSpawn())
+	w.protection = p.Rules.SpawnProtection
 	p.Tokens[w.Token] = w
 	id := p.addMovable(w)
+	log.Printf("AI %s joins running the %s strategy", w.Name, w.strategy)
 	p.announceJoin(w, id)
 }
 
@@ -462,6 +826,9 @@ func (p *Playfield) addMovable(m Movable) Id {
 	p.LastId++
 	id := p.LastId
 	p.Movables[m] = id
+	if w, ok := m.(*Worm); ok {
+		w.portals = p.portalExits
+	}
 	log.Print("New movable id:", id)
 	return id
 }
@@ -476,12 +843,16 @@ func (p *Playfield) announceJoin(m Movable, id Id) {
 	if w.Name == "" {
 		w.Name = fmt.Sprintf("Worm-%d", id)
 	}
+	p.emit(p.wormEvent(EventJoin, w))
 	// Seed the field with food on first join so a single player has
 	// something to chase. Done before the AI short-circuit below so an
 	// AI-only first join still populates the field.
-	for len(p.Foods) < FoodCount {
-		f := p.spawnFood()
-		p.Broadcast <- foodPacket(f)
+	for p.regularFoodCount() < p.Rules.FoodCount {
+		f, ok := p.spawnFood()
+		if !ok {
+			break
+		}
+		p.Broadcast <- p.foodPacket(f)
 	}
 	if w.AI {
 		// AI worms have no consumer draining their Outbox; the broadcast
@@ -500,18 +871,26 @@ func (p *Playfield) announceJoin(m Movable, id Id) {
 		Dead:        w.killed,
 		DeathReason: w.deathReason,
 		Score:       w.Score,
+		Foods:       p.Rules.Foods,
+		PlayerId:    PlayerId(w.Token),
+		Tick:        p.Rules.Tick,
 	}}
 	// Catch the new client up on current state.
-	for _, f := range p.Foods {
-		w.Outbox <- foodPacket(*f)
+	w.Outbox <- p.portalsPacket()
+	if p.Leaderboard != nil {
+		w.Outbox <- p.leaderboardPacket()
 	}
-	for other, otherId := range p.Movables {
+	for _, f := range p.sortedFoods() {
+		w.Outbox <- p.foodPacket(*f)
+	}
+	for _, other := range p.sortedMovables() {
+		otherId := p.Movables[other]
 		if ow, ok := other.(*Worm); ok {
 			w.Outbox <- scorePacket(otherId, ow)
 		}
 	}
-	if p.pacman != nil {
-		w.Outbox <- pacManPacket(p.pacman)
+	for _, pm := range p.pacmen {
+		w.Outbox <- pacManPacket(pm)
 	}
 	// Announce the new player to everyone (including itself).
 	p.Broadcast <- scorePacket(id, w)
@@ -519,6 +898,9 @@ func (p *Playfield) announceJoin(m Movable, id Id) {
 
 func (p *Playfield) removeMovable(m Movable) {
 	log.Print("Deleting movable", m)
+	if w, ok := m.(*Worm); ok {
+		p.emit(p.wormEvent(EventLeave, w))
+	}
 	m.Kill()
 	p.Broadcast <- Packet{Command: "KILL", Payload: fmt.Sprintf("%d", p.Movables[m])}
 	delete(p.Movables, m)
@@ -551,17 +933,25 @@ drained:
 		Dead:        w.killed,
 		DeathReason: w.deathReason,
 		Score:       w.Score,
+		Foods:       p.Rules.Foods,
+		PlayerId:    PlayerId(w.Token),
+		Tick:        p.Rules.Tick,
 	}}
-	for _, f := range p.Foods {
-		w.Outbox <- foodPacket(*f)
+	w.Outbox <- p.portalsPacket()
+	if p.Leaderboard != nil {
+		w.Outbox <- p.leaderboardPacket()
 	}
-	for other, otherId := range p.Movables {
+	for _, f := range p.sortedFoods() {
+		w.Outbox <- p.foodPacket(*f)
+	}
+	for _, other := range p.sortedMovables() {
+		otherId := p.Movables[other]
 		if ow, ok := other.(*Worm); ok {
 			w.Outbox <- scorePacket(otherId, ow)
 		}
 	}
-	if p.pacman != nil {
-		w.Outbox <- pacManPacket(p.pacman)
+	for _, pm := range p.pacmen {
+		w.Outbox <- pacManPacket(pm)
 	}
 	// GAMEOVER state travels in WELCOME above — no separate packet needed
 	// (and avoids racing the client's hideGameOver in the welcome handler).
@@ -571,17 +961,22 @@ drained:
 // walls / self / other snakes, then broadcast MOVE for survivors and GAMEOVER
 // for any newly-dead worm.
 func (p *Playfield) tick() {
-	// First, sweep human worms whose owners have been disconnected past the
+	p.ticks++
+	p.adaptDifficulty()
+	p.expireFoods()
+	p.fleeFoods()
+
+	// Sweep human worms whose owners have been disconnected past the
 	// TTL. With wrap-around they never die naturally, so without this they'd
 	// accumulate forever and stall announceJoin's per-worm SCORE writes.
 	now := time.Now()
 	var stale []Movable
-	for m := range p.Movables {
+	for _, m := range p.sortedMovables() {
 		w, ok := m.(*Worm)
 		if !ok || w.AI || w.connected {
 			continue
 		}
-		if !w.disconnectedAt.IsZero() && now.Sub(w.disconnectedAt) > DisconnectTTL {
+		if !w.disconnectedAt.IsZero() && now.Sub(w.disconnectedAt) > time.Duration(p.Rules.DisconnectTTL) {
 			stale = append(stale, m)
 		}
 	}
@@ -594,19 +989,20 @@ func (p *Playfield) tick() {
 
 	// AI bots only run while a human has a live websocket — keeps them from
 	// growing out of reach in an empty field.
-	anyHumanOnline := false
-	for m := range p.Movables {
-		if w, ok := m.(*Worm); ok && !w.AI && w.connected {
-			anyHumanOnline = true
-			break
+	anyHumanOnline := p.humanCount() > 0
+	switch {
+	case anyHumanOnline && !p.inRound:
+		p.inRound, p.roundStart = true, p.ticks
+		if p.ReplayDir != "" {
+			p.startReplay(p.ReplayDir)
 		}
+	case !anyHumanOnline && p.inRound:
+		p.inRound = false
+		p.emit(Event{Kind: EventRoundEnd, Ticks: p.ticks - p.roundStart})
+		p.stopReplay()
 	}
 
-	type death struct {
-		id     Id
-		reason string
-	}
-	var deaths []death
+	var deaths []*Worm
 
 	// Snapshot every living worm's pre-move head cell, so the Pac-Man bite
 	// phase can detect a head-on swap (worm head and Pac-Man trading cells).
@@ -619,25 +1015,34 @@ func (p *Playfield) tick() {
 
 	// Phase 1: move every living worm. Wall and self-collision deaths are
 	// captured by Move; record them so we can broadcast GAMEOVER at the end.
-	for m, id := range p.Movables {
+	for _, m := range p.sortedMovables() {
+		id := p.Movables[m]
 		w, isWorm := m.(*Worm)
+		if isWorm && w.respawnCooldown > 0 {
+			w.respawnCooldown--
+		}
+		if isWorm && w.respawnPending && w.respawnCooldown == 0 {
+			// A RESPAWN that arrived during the cooldown.
+			w.respawnPending = false
+			if w.killed {
+				p.respawn(w, id)
+				continue
+			}
+		}
 		if isWorm && w.killed {
 			if w.AI {
 				w.aiDeadTicks++
 				if w.aiDeadTicks >= 10 {
-					// Reset() zeroes state but stacks every block at the
-					// hardcoded center cell (25,25). Without the safe-spawn
-					// relocation below, every AI respawn lands on the same
-					// cell, head-on collisions cluster, and Pac-Man can park
-					// near center to camp the respawn lane. Mirror the human
-					// Respawn handler: relocate via safeSpawn. Reset() leaves
-					// direction = Unknown, which Worm.Direction() resolves to
-					// a random heading on first call, so siblings don't march
-					// in lockstep.
-					w.Reset()
-					placeAt(w, p.safeSpawn())
+					// Same path as a human RESPAWN: Reset() stacks every
+					// block at the hardcoded center cell (25,25), so
+					// respawn relocates via safeSpawn — otherwise every AI
+					// respawn lands on the same cell, head-on collisions
+					// cluster, and Pac-Man can park near center to camp
+					// the respawn lane. Reset() leaves direction = Unknown,
+					// which the move below resolves to a random heading,
+					// so siblings don't march in lockstep.
+					p.respawn(w, id)
 					w.aiDeadTicks = 0
-					p.Broadcast <- scorePacket(id, w)
 				}
 			}
 			continue
@@ -645,6 +1050,9 @@ func (p *Playfield) tick() {
 		if isWorm && w.AI && !anyHumanOnline {
 			continue
 		}
+		if isWorm && w.tickCombo() {
+			p.Broadcast <- scorePacket(id, w)
+		}
 		if isWorm && w.AI {
 			w.direction = pickAIDirection(w, p)
 		} else if isWorm && len(w.inputs) > 0 {
@@ -652,31 +1060,57 @@ func (p *Playfield) tick() {
 			w.direction = w.inputs[0]
 			w.inputs = w.inputs[1:]
 		}
+		if isWorm && w.direction == Unknown {
+			// Fresh or respawned and no input yet: pick a heading so the
+			// worm always advances.
+			w.direction = p.randomDirection()
+		}
 		m.Move(m.Direction())
 		if isWorm && w.killed {
-			deaths = append(deaths, death{id, w.deathReason})
+			deaths = append(deaths, w)
 		}
 	}
 
-	// Phase 1b: move Pac-Man after worms so his AI reacts to where they
-	// just landed, not where they came from. Skipped if no humans are
-	// online (he despawns then anyway via reconcilePacMan, but the guard
-	// keeps the tick cheap during the brief window before despawn lands).
-	if p.pacman != nil && anyHumanOnline {
-		p.pacman.Move(pickPacManDirection(p.pacman, p))
+	// Phase 1b: move the Pac-Men after worms so their AI reacts to where
+	// the worms just landed, not where they came from. Skipped if no humans
+	// are online (they despawn then anyway via reconcilePacMan, but the
+	// guard keeps the tick cheap during the brief window before despawn
+	// lands). An eaten Pac-Man is replaced once the respawn delay is over.
+	if len(p.pacmanRespawns) > 0 {
+		waiting := p.pacmanRespawns[:0]
+		for _, left := range p.pacmanRespawns {
+			if left > 1 {
+				waiting = append(waiting, left-1)
+			}
+		}
+		due := len(waiting) < len(p.pacmanRespawns)
+		p.pacmanRespawns = waiting
+		if due {
+			p.reconcilePacMan()
+		}
+	}
+	if anyHumanOnline {
+		for _, pm := range p.pacmen {
+			stepPacMan(pm, p)
+		}
 	}
 
 	// Phase 2: snake-on-snake — slither.io-style rules.
 	//   - If 2+ worms have heads at the same cell, they all die (head-on).
+	//     Each is credited to one of the others, though nobody survives
+	//     to collect the kill points.
 	//   - Otherwise, if a worm's head crashes into another worm's body,
 	//     the *head's* owner dies (your body is a hazard to anyone who
-	//     touches it with their head). This is the inverse of "eating from
-	//     the side", which removes the entire skill of body positioning.
+	//     touches it with their head) and the body's owner gets the kill.
+	//     This is the inverse of "eating from the side", which removes the
+	//     entire skill of body positioning.
+	//   - Spawn-protected worms are left out of both checks: they pass
+	//     through others and others pass through them.
 	headsAt := map[Position][]*Worm{}
 	bodies := map[Position]*Worm{}
-	for m := range p.Movables {
+	for _, m := range p.sortedMovables() {
 		w, ok := m.(*Worm)
-		if !ok || w.killed {
+		if !ok || w.killed || w.Protected() {
 			continue
 		}
 		for i, b := range w.blocks {
@@ -687,7 +1121,14 @@ func (p *Playfield) tick() {
 			}
 		}
 	}
-	for _, worms := range headsAt {
+	// Walked in worm order rather than over headsAt so pile-ups resolve
+	// the same way every replay.
+	for _, m := range p.sortedMovables() {
+		w, ok := m.(*Worm)
+		if !ok || w.killed || w.Protected() {
+			continue
+		}
+		worms := headsAt[w.Head()]
 		if len(worms) < 2 {
 			continue
 		}
@@ -696,18 +1137,19 @@ func (p *Playfield) tick() {
 			names = append(names, w.Name)
 		}
 		reason := "Head-on collision (" + joinNames(names) + ")"
+		// Nobody killed anybody: every worm in the pile-up dies, so none
+		// is credited with the others.
 		for _, w := range worms {
 			if w.killed {
 				continue
 			}
-			w.killed = true
-			w.deathReason = reason
-			deaths = append(deaths, death{p.Movables[w], w.deathReason})
+			w.die(CauseHeadOn, reason, nil)
+			deaths = append(deaths, w)
 		}
 	}
-	for m, id := range p.Movables {
+	for _, m := range p.sortedMovables() {
 		w, ok := m.(*Worm)
-		if !ok || w.killed {
+		if !ok || w.killed || w.Protected() {
 			continue
 		}
 		head := w.Head()
@@ -715,43 +1157,80 @@ func (p *Playfield) tick() {
 		if !hit || owner == w {
 			continue
 		}
-		w.killed = true
-		w.deathReason = "Crashed into " + owner.Name
-		deaths = append(deaths, death{id, w.deathReason})
+		w.die(CauseCrash, "Crashed into "+owner.Name, owner)
+		deaths = append(deaths, w)
 	}
 
-	// Phase 2b: Pac-Man bite. May kill (head bite) or truncate (body bite)
-	// at most one worm per tick. Broadcast BITE for the body case after we
-	// know which segments were lost; head bite falls through to the regular
-	// GAMEOVER broadcast. Gated on anyHumanOnline to match Phase 1b/3b:
-	// Pac-Man doesn't move or broadcast in human-less ticks, so he must
-	// not bite either (otherwise stationary Pac-Man could still kill AI
-	// worms in the gap before reconcilePacMan despawns him).
+	// Phase 2b: Pac-Man bites. Each hunter may kill (head bite) or truncate
+	// (body bite) at most one worm per tick, and a worm bitten by one
+	// hunter is off the menu for the rest of this tick, so two hunters
+	// closing on the same worm can't chop it twice. Broadcast BITE for the
+	// body case after we know which segments were lost; head bite falls
+	// through to the regular GAMEOVER broadcast. Gated on anyHumanOnline to
+	// match Phase 1b/3b: Pac-Men don't move or broadcast in human-less
+	// ticks, so they must not bite either (otherwise a stationary hunter
+	// could still kill AI worms in the gap before reconcilePacMan despawns
+	// him).
+	//
+	// A frightened hunter doesn't bite at all; instead the first worm head
+	// to reach his footprint eats him.
 	var bitePackets []Packet
-	if p.pacman != nil && anyHumanOnline {
-		bittenWorm, segIdx, lost := resolvePacManBite(p.pacman, prevHeads, p)
-		if bittenWorm != nil {
+	var biteRemains []Food
+	if anyHumanOnline {
+		bitten := map[*Worm]struct{}{}
+		// Ranging over a copy: eatPacMan removes hunters from p.pacmen.
+		for _, pm := range append([]*PacMan(nil), p.pacmen...) {
+			if pm.Frightened() {
+				if eater := resolvePacManEaten(pm, prevHeads, p); eater != nil {
+					p.eatPacMan(pm, eater)
+				}
+				continue
+			}
+			bittenWorm, segIdx, lost := resolvePacManBiteExcept(pm, prevHeads, p, bitten)
+			if bittenWorm == nil {
+				continue
+			}
+			bitten[bittenWorm] = struct{}{}
 			id := p.Movables[bittenWorm]
 			if segIdx == 0 {
-				deaths = append(deaths, death{id, bittenWorm.deathReason})
-			} else {
-				bitePackets = append(bitePackets, Packet{
-					Command: "BITE",
-					Payload: BitePayload{
-						WormId:        id,
-						SegmentIndex:  segIdx,
-						LostPositions: lost,
-					},
-				})
-				// Score-bar redraw — points unchanged, but clients can
-				// react (e.g., name flash) on the SCORE packet too.
-				p.Broadcast <- scorePacket(id, bittenWorm)
+				deaths = append(deaths, bittenWorm)
+				continue
+			}
+			bitePackets = append(bitePackets, Packet{
+				Command: "BITE",
+				Payload: BitePayload{
+					WormId:        id,
+					PacManId:      pm.Id,
+					SegmentIndex:  segIdx,
+					LostPositions: lost,
+				},
+			})
+			e := p.wormEvent(EventBite, bittenWorm)
+			e.PacManId, e.Lost = pm.Id, len(lost)
+			p.emit(e)
+			// Score-bar redraw — points unchanged but the multiplier
+			// reset, and clients can react (e.g., name flash) too.
+			p.Broadcast <- scorePacket(id, bittenWorm)
+			if p.Rules.BiteRemains {
+				biteRemains = append(biteRemains, p.spawnRemains(lost)...)
 			}
 		}
 	}
 
+	// Phase 2c: time bombs. Heads next to an unarmed one light its fuse;
+	// those whose fuse runs out explode now, before MOVE, so clients see
+	// the cut bodies straight away. Gated like 2b so frozen bots can't
+	// set one off in a human-less field.
+	if anyHumanOnline {
+		killed, pkts, remains := p.resolveBombs()
+		deaths = append(deaths, killed...)
+		bitePackets = append(bitePackets, pkts...)
+		biteRemains = append(biteRemains, remains...)
+	}
+
 	// Phase 3: broadcast MOVE for living worms.
-	for m, id := range p.Movables {
+	for _, m := range p.sortedMovables() {
+		id := p.Movables[m]
 		w, isWorm := m.(*Worm)
 		if isWorm && w.killed {
 			continue
@@ -760,75 +1239,290 @@ func (p *Playfield) tick() {
 			// Frozen at spawn; no need to renotify clients each tick.
 			continue
 		}
+		protected := isWorm && w.Protected()
 		p.Broadcast <- Packet{
 			Command: "MOVE",
-			Payload: MovePayload{Id: id, Positions: m.Positions()},
+			Payload: MovePayload{Id: id, Positions: m.Positions(), Protected: protected},
+		}
+		// Counted down after this tick's collisions so the last protected
+		// tick is still the one clients saw blinking.
+		if protected {
+			w.protection--
 		}
 	}
 
-	// Phase 3b: broadcast Pac-Man's current cell + facing. One packet per
-	// tick while Pac-Man is alive; clients tween the cell-step the same
-	// way they tween worm bodies.
-	if p.pacman != nil && anyHumanOnline {
-		p.Broadcast <- pacManPacket(p.pacman)
+	// Phase 3b: broadcast each Pac-Man's current cell + facing. One packet
+	// per hunter per tick; clients tween the cell-step the same way they
+	// tween worm bodies.
+	if anyHumanOnline {
+		for _, pm := range p.pacmen {
+			p.Broadcast <- pacManPacket(pm)
+		}
 	}
 
 	// Phase 4: announce deaths.
-	for _, d := range deaths {
-		p.Broadcast <- Packet{
-			Command: "GAMEOVER",
-			Payload: GameOverPayload{WormId: d.id, Reason: d.reason},
-		}
+	for _, w := range deaths {
+		p.announceDeath(w)
 	}
 
-	// Phase 4b: bite events (non-fatal). Issued after MOVE so the client
-	// already has the post-bite positions; the BITE packet only drives
-	// the puff-of-particles effect.
+	// Phase 4b: bite and blast events (non-fatal). Issued after MOVE so
+	// the client already has the post-bite positions; the BITE and
+	// EXPLODE packets only drive the puff-of-particles effect.
 	for _, pkt := range bitePackets {
 		p.Broadcast <- pkt
 	}
+	for _, f := range biteRemains {
+		p.Broadcast <- p.foodPacket(f)
+	}
 
 	// Phase 5: food pickups (head must be alive to count).
 	p.resolveFoodCollisions()
+
+	// Phase 6: external bots get the settled state to plan the next move.
+	p.sendBotStates()
+}
+
+// resolveBombs arms every time bomb (FoodKind.Fuse) with a living head on
+// or next to it, burns down the fuses already lit, and explodes the ones
+// that run out. A blast kills every unprotected worm whose head is within
+// the kind's BlastRadius and cuts the rest at the first segment inside it,
+// the same truncation a Pac-Man bite does. ARM packets go out straight
+// away; the EXPLODE, BITE and replacement FOOD packets, the worms killed
+// and any remains are returned for the tick to announce after MOVE.
+func (p *Playfield) resolveBombs() ([]*Worm, []Packet, []Food) {
+	var (
+		deaths  []*Worm
+		packets []Packet
+		remains []Food
+	)
+	for _, f := range p.sortedFoods() {
+		fid := f.Id
+		kind, _ := p.Rules.Foods.Kind(f.Type)
+		if kind.Fuse <= 0 {
+			continue
+		}
+		if !f.Armed() {
+			for _, m := range p.sortedMovables() {
+				id := p.Movables[m]
+				w, ok := m.(*Worm)
+				if !ok || w.killed || manhattan(w.Head(), f.Position) > 1 {
+					continue
+				}
+				f.fuse = kind.Fuse
+				f.armedBy = w
+				p.Broadcast <- Packet{
+					Command: "ARM",
+					Payload: ArmPayload{FoodId: fid, WormId: id, Fuse: f.fuse * p.Rules.Tick},
+				}
+				break
+			}
+			continue
+		}
+		f.fuse--
+		if f.fuse > 0 {
+			continue
+		}
+
+		delete(p.Foods, fid)
+		cells := blastCells(f.Position, kind.BlastRadius)
+		blast := posSet(cells)
+		packets = append(packets, Packet{
+			Command: "EXPLODE",
+			Payload: ExplodePayload{FoodId: fid, X: f.Position.X, Y: f.Position.Y, Cells: cells},
+		})
+		for _, m := range p.sortedMovables() {
+			id := p.Movables[m]
+			w, ok := m.(*Worm)
+			if !ok || w.killed || w.Protected() {
+				continue
+			}
+			for i, b := range w.blocks {
+				if _, hit := blast[b]; !hit {
+					continue
+				}
+				if i == 0 {
+					var killer *Worm
+					if f.armedBy != w {
+						killer = f.armedBy
+					}
+					w.die(CauseBlast, "Caught in a blast", killer)
+					deaths = append(deaths, w)
+					break
+				}
+				lost := w.cut(i)
+				packets = append(packets, Packet{
+					Command: "BITE",
+					Payload: BitePayload{WormId: id, SegmentIndex: i, LostPositions: lost},
+				})
+				e := p.wormEvent(EventBite, w)
+				e.Lost = len(lost)
+				p.emit(e)
+				p.Broadcast <- scorePacket(id, w)
+				if p.Rules.BiteRemains {
+					remains = append(remains, p.spawnRemains(lost)...)
+				}
+				break
+			}
+		}
+		if nf, ok := p.spawnFood(); ok {
+			packets = append(packets, p.foodPacket(nf))
+		}
+	}
+	return deaths, packets, remains
+}
+
+// announceDeath broadcasts GAMEOVER plus a KILLFEED line for a worm that
+// just died, records the life on the leaderboard and in the player's
+// profile, credits Rules.KillPoints to a killer that is still alive, and
+// drops the body as remains. The remains are spawned per death so a
+// head-on pair doesn't leave two items on the shared head cell.
+func (p *Playfield) announceDeath(w *Worm) {
+	id := p.Movables[w]
+	var killerId Id
+	killerName := ""
+	if w.killer != nil {
+		killerId = p.Movables[w.killer]
+		killerName = w.killer.Name
+	}
+	p.countDeath(w.deathCause)
+	e := p.wormEvent(EventDeath, w)
+	e.Cause, e.Reason, e.KillerId, e.Killer = w.deathCause, w.deathReason, killerId, killerName
+	p.emit(e)
+	p.Broadcast <- Packet{
+		Command: "GAMEOVER",
+		Payload: GameOverPayload{
+			WormId:   id,
+			Reason:   w.deathReason,
+			Cause:    w.deathCause,
+			KillerId: killerId,
+		},
+	}
+	p.Broadcast <- Packet{
+		Command: "KILLFEED",
+		Payload: KillFeedPayload{
+			VictimId:   id,
+			VictimName: w.Name,
+			KillerId:   killerId,
+			KillerName: killerName,
+			Cause:      w.deathCause,
+		},
+	}
+	w.respawnCooldown = p.Rules.RespawnCooldown
+	p.Leaderboard.Record(LifeRecord{
+		Name:   w.Name,
+		Room:   p.Room,
+		Score:  w.Score,
+		Length: len(w.blocks),
+		Cause:  w.deathCause,
+		Bot:    w.AI || w.Bot,
+	})
+	if !w.AI {
+		p.Profiles.RecordLife(w.Token, w.Name, w.Score, len(w.blocks), w.deathCause)
+	}
+	if k := w.killer; k != nil && !k.AI {
+		p.Profiles.RecordKill(k.Token, k.Name)
+	}
+	if k := w.killer; k != nil && killerId != 0 && !k.killed && p.Rules.KillPoints > 0 {
+		k.AddScore(p.Rules.KillPoints)
+		p.Broadcast <- scorePacket(killerId, k)
+	}
+	for _, f := range p.spawnRemains(w.blocks) {
+		p.Broadcast <- p.foodPacket(f)
+	}
+}
+
+// requestRespawn handles a RESPAWN from w. Only a dead worm is brought
+// back; one still on its cooldown is marked pending for the tick.
+func (p *Playfield) requestRespawn(w *Worm) {
+	id, ok := p.Movables[w]
+	if !ok || !w.killed {
+		return
+	}
+	if w.respawnCooldown > 0 {
+		// Too soon — the tick applies it once the cooldown has run out.
+		w.respawnPending = true
+		return
+	}
+	p.respawn(w, id)
+}
+
+// respawn brings w back from a GAMEOVER on a safe cell with spawn
+// protection and restarts its respawn cooldown. Humans also get a fresh
+// WELCOME so the client drops its game-over dialog.
+func (p *Playfield) respawn(w *Worm, id Id) {
+	w.Reset()
+	placeAt(w, p.safeSpawn())
+	w.protection = p.Rules.SpawnProtection
+	w.respawnCooldown = p.Rules.RespawnCooldown
+	p.emit(p.wormEvent(EventRespawn, w))
+	if !w.AI {
+		w.Outbox <- Packet{
+			Command: "WELCOME",
+			Payload: WelcomePayload{Id: id, Name: w.Name, Token: w.Token, Foods: p.Rules.Foods, PlayerId: PlayerId(w.Token)},
+		}
+		if p.Leaderboard != nil {
+			w.Outbox <- p.leaderboardPacket()
+		}
+	}
+	p.Broadcast <- scorePacket(id, w)
 }
 
 // resolveFoodCollisions checks each living worm's head against every food.
-// On a fruit match: credit score, broadcast EAT/SCORE. On a bomb match:
-// the worm dies; broadcast EAT/GAMEOVER instead. Either way the food is
-// removed and a replacement spawned so the field stays full.
+// On an edible match: credit score at the worm's combo multiplier, broadcast
+// EAT/SCORE. On a lethal match
+// (a bomb, or whatever kind is Lethal): the worm dies of the kind's Cause; broadcast EAT/GAMEOVER and drop its remains instead.
+// Either way the food is removed and, unless it was remains, a replacement
+// spawned so the field stays full.
 func (p *Playfield) resolveFoodCollisions() {
-	for m, id := range p.Movables {
+	for _, m := range p.sortedMovables() {
+		id := p.Movables[m]
 		w, ok := m.(*Worm)
 		if !ok || w.killed {
 			continue
 		}
 		head := w.Head()
-		for fid, f := range p.Foods {
+		for _, f := range p.sortedFoods() {
+			fid := f.Id
 			if head != f.Position {
 				continue
 			}
 			delete(p.Foods, fid)
 			p.Broadcast <- Packet{Command: "EAT", Payload: EatPayload{FoodId: fid, WormId: id}}
-			if f.Type == Bomb {
-				w.killed = true
-				w.deathReason = "Stepped on a bomb"
-				p.Broadcast <- Packet{
-					Command: "GAMEOVER",
-					Payload: GameOverPayload{WormId: id, Reason: w.deathReason},
-				}
+			if kind, _ := p.Rules.Foods.Kind(f.Type); kind.Lethal {
+				w.die(kind.deathCause(), "Stepped on a "+string(f.Type), nil)
+				p.announceDeath(w)
 			} else {
-				w.AddScore(f.Points())
+				credited, _ := w.Eat(kind.Points, p.Rules.Combo)
+				e := p.wormEvent(EventEat, w)
+				e.Food, e.Points = f.Type, credited
+				p.emit(e)
 				p.Broadcast <- scorePacket(id, w)
+				if f.Type == PowerPellet {
+					for _, pm := range p.pacmen {
+						pm.Frighten(p.Rules.PacManFrightenedTicks)
+						p.Broadcast <- pacManPacket(pm)
+					}
+				}
+			}
+			if f.Type != Remains {
+				if nf, ok := p.spawnFood(); ok {
+					p.Broadcast <- p.foodPacket(nf)
+				}
 			}
-			nf := p.spawnFood()
-			p.Broadcast <- foodPacket(nf)
 			break
 		}
 	}
 }
 
+// tickLength is how long one of the room's ticks lasts.
+func (p *Playfield) tickLength() time.Duration {
+	return time.Duration(p.Rules.Tick) * time.Millisecond
+}
+
 func (p *Playfield) Start() {
 	log.Println("Playfield starting")
+	p.placePortals(p.Rules.PortalPairs)
+	p.Ticker.Reset(p.tickLength())
 	go func() {
 		for {
 			select {
@@ -839,6 +1533,8 @@ func (p *Playfield) Start() {
 				p.removeMovable(m)
 			case req := <-p.Attach:
 				if existing, ok := p.Tokens[req.Token]; ok && req.Token != "" {
+					existing.Bot = req.Bot
+					p.Profiles.Attach(req.Token, "")
 					id := p.Movables[existing]
 					p.resyncWorm(existing, id)
 					req.Reply <- AttachReply{Worm: existing, Id: id}
@@ -846,19 +1542,25 @@ func (p *Playfield) Start() {
 				}
 				w := NewWorm()
 				w.Token = req.Token
+				w.Bot = req.Bot
 				if req.Name != "" {
 					w.Name = req.Name
 				}
+				// A returning player who didn't say who they are keeps
+				// the name their profile remembers.
+				if prof := p.Profiles.Attach(req.Token, req.Name); w.Name == "" {
+					w.Name = prof.Name
+				}
 				placeAt(w, p.safeSpawn())
+				w.protection = p.Rules.SpawnProtection
 				p.Tokens[w.Token] = w
 				id := p.addMovable(w)
 				p.announceJoin(w, id)
 				req.Reply <- AttachReply{Worm: w, Id: id}
 			case req := <-p.Rename:
-				req.Worm.Name = req.Name
-				if id, ok := p.Movables[req.Worm]; ok {
-					p.Broadcast <- scorePacket(id, req.Worm)
-				}
+				p.rename(req.Worm, req.Name)
+			case req := <-p.Admin:
+				req.Reply <- p.admin(req)
 			case s := <-p.ConnState:
 				s.Worm.connected = s.Connected
 				if s.Connected {
@@ -893,39 +1595,75 @@ func (p *Playfield) Start() {
 				}
 				w.inputs = append(w.inputs, req.Direction)
 			case <-p.Ticker.C:
+				started := time.Now()
+				skipped := p.tickStarted(started)
+				// Deliver what's still queued first, so every packet
+				// reaches clients (and the replay) under the tick that
+				// sent it.
+				p.drainBroadcast()
+				p.flushReplay()
 				p.tick()
+				busy := time.Since(started)
+				p.observeTick(busy, skipped)
+				p.adjustLoad(busy, skipped)
 			case req := <-p.Respawn:
-				req.Worm.Reset()
-				placeAt(req.Worm, p.safeSpawn())
-				id, ok := p.Movables[req.Worm]
-				if !ok {
-					break
-				}
-				req.Worm.Outbox <- Packet{
-					Command: "WELCOME",
-					Payload: WelcomePayload{Id: id, Name: req.Worm.Name, Token: req.Worm.Token},
-				}
-				p.Broadcast <- scorePacket(id, req.Worm)
+				p.requestRespawn(req.Worm)
 			case packet := <-p.Broadcast:
-				for m, id := range p.Movables {
-					// AI worms have no websocket consumer draining their
-					// Outbox, so writes would just fill the buffer and then
-					// log "Could not send packet" forever.
-					if w, ok := m.(*Worm); ok && w.AI {
-						continue
-					}
-					c := m.Channel()
-					select {
-					case c <- packet:
-					default:
-						log.Print("Could not send packet to movable:", id)
-					}
-				}
+				p.deliver(packet)
 			}
 		}
 	}()
 }
 
+// rename gives w a new name and tells everyone.
+func (p *Playfield) rename(w *Worm, name string) {
+	id, onField := p.Movables[w]
+	if onField && w.Name != name {
+		e := p.wormEvent(EventRename, w)
+		e.Name, e.OldName = name, w.Name
+		p.emit(e)
+	}
+	w.Name = name
+	if !w.AI {
+		p.Profiles.Rename(w.Token, name)
+	}
+	if onField {
+		p.Broadcast <- scorePacket(id, w)
+	}
+}
+
+// deliver fans a broadcast packet out to every client and records it.
+func (p *Playfield) deliver(packet Packet) {
+	p.recordPacket(packet)
+	for m, id := range p.Movables {
+		// AI worms have no websocket consumer draining their
+		// Outbox, so writes would just fill the buffer and then
+		// log "Could not send packet" forever.
+		if w, ok := m.(*Worm); ok && (w.AI || p.spectatorSkips(w, packet)) {
+			continue
+		}
+		c := m.Channel()
+		select {
+		case c <- packet:
+		default:
+			p.countDrop()
+			log.Print("Could not send packet to movable:", id)
+		}
+	}
+}
+
+// drainBroadcast delivers every packet waiting in Broadcast.
+func (p *Playfield) drainBroadcast() {
+	for {
+		select {
+		case packet := <-p.Broadcast:
+			p.deliver(packet)
+		default:
+			return
+		}
+	}
+}
+
 func (p *Playfield) Stop() {
 	p.Ticker.Stop()
 }
@@ -0,0 +1,78 @@
+package flow
+
+// Portal is a pair of linked cells. A head (or Pac-Man's anchor) stepping
+// onto either cell comes out on the other with its heading kept; the body
+// follows through because every segment retraces the head's cells.
+type Portal struct {
+	A Position
+	B Position
+}
+
+// portalMinSpan is the minimum manhattan distance between the two ends of
+// a portal, so a jump is always worth taking.
+const portalMinSpan = (Boundary + 1) / 2
+
+// MaxPortalPairs caps Rules.PortalPairs; a field packed fuller than this
+// has little room left between portal ends.
+const MaxPortalPairs = 16
+
+// placePortals adds n portal pairs on free cells. Ends keep off worms,
+// food, Pac-Men and each other's neighbourhood so no one is dropped on top
+// of something or straight into a second portal.
+func (p *Playfield) placePortals(n int) {
+	blocked := p.occupied()
+	free := func(c Position) bool {
+		if _, taken := blocked[c]; taken {
+			return false
+		}
+		for _, d := range []Direction{Up, Down, Left, Right} {
+			if _, ok := p.portalExits[wrap(step(c, d))]; ok {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; i < n; i++ {
+		for tries := 0; tries < 200; tries++ {
+			a, b := p.randomCell(), p.randomCell()
+			if !free(a) || !free(b) || manhattan(a, b) < portalMinSpan {
+				continue
+			}
+			p.portals = append(p.portals, Portal{A: a, B: b})
+			p.portalExits[a] = b
+			p.portalExits[b] = a
+			break
+		}
+	}
+}
+
+// advance is the cell reached by stepping d from pos: step, wrap, and
+// through a portal if it lands on one. Everything that predicts movement
+// goes through here so it agrees with Worm.Move.
+func (p *Playfield) advance(pos Position, d Direction) Position {
+	next := wrap(step(pos, d))
+	if exit, ok := p.portalExits[next]; ok {
+		return exit
+	}
+	return next
+}
+
+// distance is manhattan distance allowing for portal shortcuts: the
+// shorter of going direct, or walking onto either end of a portal and
+// carrying on from the other.
+func (p *Playfield) distance(a, b Position) int {
+	best := manhattan(a, b)
+	for _, pt := range p.portals {
+		if d := manhattan(a, pt.A) + manhattan(pt.B, b); d < best {
+			best = d
+		}
+		if d := manhattan(a, pt.B) + manhattan(pt.A, b); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+func (p *Playfield) portalsPacket() Packet {
+	return Packet{Command: "PORTALS", Payload: PortalsPayload{Portals: p.portals}}
+}
@@ -0,0 +1,60 @@
+package flow
+
+import "testing"
+
+func portalField(a, b Position) *Playfield {
+	p := NewPlayfield()
+	p.portals = []Portal{{A: a, B: b}}
+	p.portalExits[a] = b
+	p.portalExits[b] = a
+	return p
+}
+
+func TestWormExitsPartnerPortal(t *testing.T) {
+	p := portalField(Position{11, 10}, Position{40, 30})
+	w := NewWorm()
+	w.blocks = []Position{{10, 10}, {9, 10}, {8, 10}}
+	w.direction = Right
+	p.addMovable(w)
+
+	w.Move(Right)
+	if got := w.Head(); got != (Position{40, 30}) {
+		t.Fatalf("Expected head on the partner portal, got %v", got)
+	}
+	if w.Direction() != Right {
+		t.Errorf("Heading should be kept through a portal, got %v", w.Direction())
+	}
+
+	w.Move(Right)
+	w.Move(Right)
+	want := []Position{{42, 30}, {41, 30}, {40, 30}}
+	for i, b := range w.blocks {
+		if b != want[i] {
+			t.Fatalf("Body should follow through the portal, got %v", w.blocks)
+		}
+	}
+}
+
+func TestDistanceUsesPortalShortcut(t *testing.T) {
+	p := portalField(Position{5, 5}, Position{30, 30})
+	from, to := Position{4, 5}, Position{31, 30}
+	if got := p.distance(from, to); got != 2 {
+		t.Errorf("Expected the portal route (2 steps), got %d", got)
+	}
+	if got := p.advance(from, Right); got != (Position{30, 30}) {
+		t.Errorf("advance should follow the portal, got %v", got)
+	}
+}
+
+func TestPlacePortalsKeepsEndsApart(t *testing.T) {
+	p := NewPlayfield()
+	p.placePortals(3)
+	if len(p.portals) != 3 {
+		t.Fatalf("Expected 3 portal pairs, got %d", len(p.portals))
+	}
+	for _, pt := range p.portals {
+		if manhattan(pt.A, pt.B) < portalMinSpan {
+			t.Errorf("Portal ends too close: %v", pt)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package flow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ProfileFlushInterval is how often changed profiles are written out by
+// default.
+// Deaths come in bursts; writing the whole store on each would be wasted.
+const ProfileFlushInterval = 10 * time.Second
+
+// Profile is a player's durable identity: what they go by and how they've
+// done across every room and restart.
+type Profile struct {
+	// Id is public, derived from the session token (see PlayerId); the
+	// token itself stays the secret that proves ownership.
+	Id         string
+	Name       string
+	Games      int // finished lives
+	BestScore  int
+	TotalScore int
+	BestLength int
+	Kills      int
+	Deaths     map[DeathCause]int
+	FirstSeen  time.Time
+	LastSeen   time.Time
+}
+
+// PlayerId derives the public profile id from a session token.
+func PlayerId(token string) string {
+	sum := sha256.Sum256([]byte("flow-player:" + token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Profiles stores every player's Profile in a JSON file, keyed by id.
+// A profile is only started by a player's first finished life or kill, so
+// tokens that connect and leave without playing leave nothing behind.
+// Changes are kept in memory and written every flush interval (see
+// OpenProfiles) and on Close. A nil *Profiles remembers nothing, so
+// playfields run fine without one.
+type Profiles struct {
+	mu   sync.Mutex
+	path string
+	byId map[string]*Profile
+	// changes counts updates; written is what changes was when the last
+	// successful write took its snapshot.
+	changes int
+	written int
+	stop    chan struct{}
+	done    chan struct{}
+	now     func() time.Time
+}
+
+// OpenProfiles loads the store at path and writes changes back every
+// flushEvery. An empty path keeps profiles in memory only.
+func OpenProfiles(path string, flushEvery time.Duration) (*Profiles, error) {
+	ps := &Profiles{path: path, byId: map[string]*Profile{}, now: time.Now}
+	if path == "" {
+		return ps, nil
+	}
+	raw, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(raw, &ps.byId); err != nil {
+			return nil, fmt.Errorf("profiles %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	ps.stop = make(chan struct{})
+	ps.done = make(chan struct{})
+	go func() {
+		defer close(ps.done)
+		t := time.NewTicker(flushEvery)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if err := ps.Flush(); err != nil {
+					log.Printf("Writing profiles: %v", err)
+				}
+			case <-ps.stop:
+				return
+			}
+		}
+	}()
+	return ps, nil
+}
+
+// Close stops the background writer and flushes what's left.
+func (ps *Profiles) Close() error {
+	if ps == nil || ps.stop == nil {
+		return nil
+	}
+	close(ps.stop)
+	<-ps.done
+	ps.stop = nil
+	return ps.Flush()
+}
+
+// Flush writes the store out if anything changed since the last write.
+func (ps *Profiles) Flush() error {
+	if ps == nil || ps.path == "" {
+		return nil
+	}
+	ps.mu.Lock()
+	if ps.changes == ps.written {
+		ps.mu.Unlock()
+		return nil
+	}
+	raw, err := json.MarshalIndent(ps.byId, "", "  ")
+	snapshot := ps.changes
+	ps.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := ps.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, ps.path); err != nil {
+		return err
+	}
+	// Only now are those changes safe; a failed write leaves them for
+	// the next Flush.
+	ps.mu.Lock()
+	ps.written = snapshot
+	ps.mu.Unlock()
+	return nil
+}
+
+// update runs fn on token's profile. Only create starts a profile for a
+// token without one; otherwise ok is false and nothing changes. Called
+// without mu held.
+func (ps *Profiles) update(token string, create bool, fn func(*Profile)) (Profile, bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	id := PlayerId(token)
+	p, ok := ps.byId[id]
+	if !ok {
+		if !create {
+			return Profile{}, false
+		}
+		now := ps.now()
+		p = &Profile{Id: id, Deaths: map[DeathCause]int{}, FirstSeen: now, LastSeen: now}
+		ps.byId[id] = p
+	}
+	fn(p)
+	ps.changes++
+	return p.copy(), true
+}
+
+func (p *Profile) copy() Profile {
+	out := *p
+	out.Deaths = make(map[DeathCause]int, len(p.Deaths))
+	for c, n := range p.Deaths {
+		out.Deaths[c] = n
+	}
+	return out
+}
+
+// Attach loads token's profile, if it has one, as its worm joins, noting
+// the visit. A non-empty name replaces the stored one.
+func (ps *Profiles) Attach(token, name string) Profile {
+	if ps == nil {
+		return Profile{}
+	}
+	prof, _ := ps.update(token, false, func(p *Profile) {
+		p.LastSeen = ps.now()
+		if name != "" {
+			p.Name = name
+		}
+	})
+	return prof
+}
+
+// Rename stores token's new display name, if it has a profile.
+func (ps *Profiles) Rename(token, name string) {
+	if ps == nil {
+		return
+	}
+	ps.update(token, false, func(p *Profile) { p.Name = name })
+}
+
+// RecordLife adds a finished life to token's stats, starting its profile
+// under name if this is the first.
+func (ps *Profiles) RecordLife(token, name string, score, length int, cause DeathCause) {
+	if ps == nil {
+		return
+	}
+	ps.update(token, true, func(p *Profile) {
+		if p.Name == "" {
+			p.Name = name
+		}
+		p.Games++
+		p.TotalScore += score
+		p.BestScore = max(p.BestScore, score)
+		p.BestLength = max(p.BestLength, length)
+		p.Deaths[cause]++
+		p.LastSeen = ps.now()
+	})
+}
+
+// RecordKill credits token with a kill, starting its profile under name
+// if it has none yet.
+func (ps *Profiles) RecordKill(token, name string) {
+	if ps == nil {
+		return
+	}
+	ps.update(token, true, func(p *Profile) {
+		if p.Name == "" {
+			p.Name = name
+		}
+		p.Kills++
+	})
+}
+
+// Get looks a profile up by its public id.
+func (ps *Profiles) Get(id string) (Profile, bool) {
+	if ps == nil {
+		return Profile{}, false
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	p, ok := ps.byId[id]
+	if !ok {
+		return Profile{}, false
+	}
+	return p.copy(), true
+}
+
+// profiles is what lobby playfields and connections use; see SetProfiles.
+var profiles *Profiles
+
+// SetProfiles makes playfields created from now on, and every connection,
+// keep player profiles in ps, and serves it from PlayersHandler.
+func SetProfiles(ps *Profiles) {
+	lobby.mu.Lock()
+	profiles = ps
+	lobby.mu.Unlock()
+}
+
+func currentProfiles() *Profiles {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+	return profiles
+}
+
+// PlayersHandler serves GET /players/{id} with the profile as JSON.
+func PlayersHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := currentProfiles().Get(r.PathValue("id"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+	})
+}
@@ -0,0 +1,125 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileFollowsAttachAndDeaths(t *testing.T) {
+	p := NewPlayfield()
+	p.Profiles, _ = OpenProfiles("", ProfileFlushInterval)
+	p.Profiles.Attach("tok", "ada")
+
+	w := NewWorm()
+	w.Token = "tok"
+	w.Name = "ada"
+	killer := NewWorm()
+	killer.Token = "other"
+	p.addMovable(w)
+	p.addMovable(killer)
+	w.Score = 35
+	w.die(CauseCrash, "Crashed into other", killer)
+	p.announceDeath(w)
+
+	prof, ok := p.Profiles.Get(PlayerId("tok"))
+	if !ok {
+		t.Fatal("Expected ada's profile")
+	}
+	if prof.Name != "ada" || prof.Games != 1 || prof.BestScore != 35 || prof.Deaths[CauseCrash] != 1 {
+		t.Errorf("Unexpected profile after one death: %+v", prof)
+	}
+	if k, _ := p.Profiles.Get(PlayerId("other")); k.Kills != 1 {
+		t.Errorf("Expected the killer to be credited, got %+v", k)
+	}
+}
+
+func TestReturningPlayerKeepsName(t *testing.T) {
+	p := NewPlayfield()
+	p.Profiles, _ = OpenProfiles("", ProfileFlushInterval)
+	p.Profiles.RecordLife("tok", "ada", 10, 4, CauseSelf)
+	p.Start()
+	defer p.Stop()
+
+	reply := make(chan AttachReply, 1)
+	p.Attach <- AttachRequest{Token: "tok", Reply: reply}
+	w := (<-reply).Worm
+	if w.Name != "ada" {
+		t.Errorf("Expected the profile's name, got %q", w.Name)
+	}
+}
+
+func TestProfilesPersistAndServe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	ps, err := OpenProfiles(path, ProfileFlushInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps.RecordLife("tok", "ada", 50, 12, CausePacMan)
+	if err := ps.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ps, err = OpenProfiles(path, ProfileFlushInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ps.Close()
+	SetProfiles(ps)
+	defer SetProfiles(nil)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /players/{id}", PlayersHandler())
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/players/"+PlayerId("tok"), nil))
+	var prof Profile
+	if err := json.Unmarshal(rec.Body.Bytes(), &prof); err != nil {
+		t.Fatalf("Bad JSON %q: %v", rec.Body.String(), err)
+	}
+	if prof.Name != "ada" || prof.BestLength != 12 || prof.Games != 1 {
+		t.Errorf("Expected ada's profile to survive a restart, got %+v", prof)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/players/nobody", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown id, got %d", rec.Code)
+	}
+}
+
+func TestProfileStartsWithFirstLife(t *testing.T) {
+	ps, _ := OpenProfiles("", ProfileFlushInterval)
+	ps.Attach("drifter", "ada")
+	ps.Rename("drifter", "bob")
+	if _, ok := ps.Get(PlayerId("drifter")); ok {
+		t.Fatal("Expected no profile for a token that never played")
+	}
+	ps.RecordKill("drifter", "bob")
+	if prof, ok := ps.Get(PlayerId("drifter")); !ok || prof.Name != "bob" || prof.Kills != 1 {
+		t.Errorf("Expected a kill to start bob's profile, got %+v", prof)
+	}
+}
+
+func TestFailedFlushKeepsChanges(t *testing.T) {
+	dir := t.TempDir()
+	ps, _ := OpenProfiles("", ProfileFlushInterval)
+	ps.path = filepath.Join(dir, "missing", "profiles.json")
+	ps.RecordLife("tok", "ada", 50, 12, CausePacMan)
+	if err := ps.Flush(); err == nil {
+		t.Fatal("Expected writing into a missing directory to fail")
+	}
+	ps.path = filepath.Join(dir, "profiles.json")
+	if err := ps.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	ps, err := OpenProfiles(ps.path, ProfileFlushInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ps.Close()
+	if prof, ok := ps.Get(PlayerId("tok")); !ok || prof.Games != 1 {
+		t.Errorf("Expected the change written after the failed flush, got %+v", prof)
+	}
+}
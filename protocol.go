@@ -2,6 +2,7 @@ package flow
 
 import (
 	"fmt"
+	"time"
 )
 
 type Position struct {
@@ -21,6 +22,7 @@ type Packet struct {
 type MovePayload struct {
 	Id        Id
 	Positions []Position
+	Protected bool // spawn protection active; clients render it blinking
 }
 
 type HelloPayload struct {
@@ -35,6 +37,13 @@ type WelcomePayload struct {
 	Dead        bool   // worm is currently in a GAMEOVER state
 	DeathReason string // populated when Dead is true
 	Score       int    // current score, included so the dialog can show it
+	// Foods is the playfield's food catalogue, so clients know how to
+	// draw every kind without hardcoding them.
+	Foods FoodCatalogue
+	// PlayerId is the player's public profile id (GET /players/{id}).
+	PlayerId string
+	// Tick is the room's tick in milliseconds, which clients tween over.
+	Tick int
 }
 
 type FoodPayload struct {
@@ -43,6 +52,35 @@ type FoodPayload struct {
 	Y      int
 	Type   FoodType
 	Points int
+	TTL    int // milliseconds until the food fades out; 0 means never
+}
+
+// FoodExpirePayload tells clients a food ran out of lifetime uneaten.
+type FoodExpirePayload struct {
+	FoodId Id
+}
+
+// FoodMovePayload is a fleeing food's new cell.
+type FoodMovePayload struct {
+	FoodId Id
+	X      int
+	Y      int
+}
+
+// ArmPayload tells clients a bomb's fuse is burning.
+type ArmPayload struct {
+	FoodId Id
+	WormId Id  // whose head armed it
+	Fuse   int // milliseconds until it explodes
+}
+
+// ExplodePayload is a bomb going off. Cells is the whole blast area; worms
+// cut by it also get a BITE, and those killed a GAMEOVER.
+type ExplodePayload struct {
+	FoodId Id
+	X      int
+	Y      int
+	Cells  []Position
 }
 
 type EatPayload struct {
@@ -51,24 +89,118 @@ type EatPayload struct {
 }
 
 type ScorePayload struct {
-	WormId Id
-	Name   string
-	Score  int
+	WormId     Id
+	Name       string
+	Score      int
+	Protected  bool
+	Multiplier int    // current combo multiplier, 1 when no streak is running
+	Strategy   string // server bots only: the Strategy driving it
+	Bot        bool   // a server bot or an external bot-API program
 }
 
 type GameOverPayload struct {
-	WormId Id
-	Reason string
+	WormId   Id
+	Reason   string     // human-readable, shown in the GAMEOVER dialog
+	Cause    DeathCause // machine-readable counterpart of Reason
+	KillerId Id         // 0 when nobody gets credit for the kill
+}
+
+// KillFeedPayload is broadcast for every death so clients can show a
+// running "who killed whom" feed. KillerId/KillerName are empty for
+// self-inflicted and environmental deaths.
+type KillFeedPayload struct {
+	VictimId   Id
+	VictimName string
+	KillerId   Id
+	KillerName string
+	Cause      DeathCause
 }
 
 type PacManPayload struct {
+	Id        Id
+	Kind      PacManKind
 	X         int
 	Y         int
 	Direction string
+	State     PacManState
+}
+
+// PacManKillPayload rides on PACMAN_KILL. WormId is the worm that ate a
+// frightened Pac-Man, or 0 when he simply despawned.
+type PacManKillPayload struct {
+	PacManId Id
+	WormId   Id
 }
 
 type BitePayload struct {
 	WormId        Id
+	PacManId      Id // zero when a bomb blast did the cutting
 	SegmentIndex  int
 	LostPositions []Position
 }
+
+// PortalsPayload lists every portal pair on the field. Sent once after
+// WELCOME; portals don't move.
+type PortalsPayload struct {
+	Portals []Portal
+}
+
+// StatePayload is the full field as one external bot sees it, sent every
+// tick through the bot API. You is the bot's own worm; Deadline is how many
+// milliseconds it has to answer with MOVE.
+type StatePayload struct {
+	Tick     int
+	You      Id
+	Deadline int
+	Width    int
+	Height   int
+	Worms    []WormState
+	Foods    []FoodState
+	PacMen   []PacManPayload
+	Portals  []Portal
+}
+
+// WormState is one living worm in a STATE frame, head first.
+type WormState struct {
+	Id        Id
+	Name      string
+	Positions []Position
+	Direction string
+	Score     int
+	Protected bool
+	Bot       bool
+}
+
+// FoodState is one food in a STATE frame.
+type FoodState struct {
+	Id     Id
+	X      int
+	Y      int
+	Type   FoodType
+	Points int
+	Lethal bool
+	Armed  bool
+}
+
+// LeaderboardPayload follows WELCOME when the server keeps a leaderboard:
+// the best human lives of all time, of today (UTC) and of this room.
+type LeaderboardPayload struct {
+	AllTime []LifeRecord
+	Today   []LifeRecord
+	Room    []LifeRecord
+}
+
+// ReplayPayload opens a replay stream (see ReplayWatchHandler): which
+// recording it is, the tick range a SEEK may aim at, the speeds SPEED
+// accepts and the food catalogue, as WELCOME would carry it.
+type ReplayPayload struct {
+	Name      string
+	Room      string
+	Seed      uint64
+	Started   time.Time
+	FirstTick int
+	LastTick  int
+	Speeds    []int
+	Foods     FoodCatalogue
+	Tick      int // milliseconds per tick when recorded
+}
@@ -0,0 +1,559 @@
+package flow
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// ReplayMaxTicks is how long one replay file runs by default before the
+// recorder starts the next, so files stay small enough to load and seek
+// through.
+const ReplayMaxTicks = 3000 // 10 minutes at the default Tick
+
+// ReplayKeep is how many replay files the directory holds by default;
+// starting a new one deletes the oldest past it.
+const ReplayKeep = 200
+
+// ReplaySpeeds are the playback rates a spectator may pick.
+var ReplaySpeeds = []int{1, 2, 4}
+
+// ReplayHeader is the first line of a replay file: everything a spectator
+// needs before the first frame.
+type ReplayHeader struct {
+	Version   int
+	Room      string
+	Seed      uint64
+	Started   time.Time
+	FirstTick int
+	Tick      int // milliseconds per tick
+	Foods     FoodCatalogue
+	Portals   []Portal
+}
+
+// ReplayFrame is every following line: one broadcast packet and the tick
+// it went out on. Each file opens with frames that rebuild the field as it
+// was when the file started (see snapshotPackets), so every file plays on
+// its own.
+type ReplayFrame struct {
+	Tick   int
+	Packet Packet
+}
+
+// ReplayBuffer is how many frames a recording holds for its writer before
+// it starts dropping them.
+const ReplayBuffer = 4096
+
+// replayRecorder hands a playfield's broadcasts to a writer goroutine,
+// which does all the replay disk work: creating files, writing frames,
+// flushing and pruning. The playfield goroutine owns firstTick, lost and
+// dropped; the writer owns file, w, enc and path.
+type replayRecorder struct {
+	queue chan replayEntry
+	done  chan struct{}
+
+	// firstTick is where the current file starts. lost is set when a
+	// frame was dropped since, which leaves the file unplayable past that
+	// point, so the playfield starts a new one as soon as there's room.
+	firstTick int
+	lost      bool
+	dropped   int
+
+	file *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+	path string
+}
+
+// replayEntry is one thing for the writer to do: start the file name with
+// header, flush at the end of a tick, or append frame.
+type replayEntry struct {
+	name   string
+	header *ReplayHeader
+	flush  bool
+	frame  ReplayFrame
+}
+
+// replayDir is where lobby playfields record to; see SetReplayDir.
+// replayMaxTicks and replayKeep bound what they record; see
+// SetReplayLimits.
+var (
+	replayDir      string
+	replayMaxTicks = ReplayMaxTicks
+	replayKeep     = ReplayKeep
+)
+
+// SetReplayDir makes playfields created from now on record replays into
+// dir, and serves that directory from ReplaysHandler. Empty turns
+// recording off.
+func SetReplayDir(dir string) {
+	lobby.mu.Lock()
+	replayDir = dir
+	lobby.mu.Unlock()
+}
+
+// SetReplayLimits sets how many ticks each replay file of playfields
+// created from now on runs, and how many files the directory keeps.
+func SetReplayLimits(maxTicks, keep int) {
+	lobby.mu.Lock()
+	replayMaxTicks, replayKeep = maxTicks, keep
+	lobby.mu.Unlock()
+}
+
+func currentReplayDir() string {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+	return replayDir
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// startReplay starts recording into dir: a writer goroutine for the
+// recording, and its first file (see rotateReplay). The playfield records
+// while a round is on (see tick), so an empty room fills no disk. Errors
+// are logged and cost the replay, not the game.
+func (p *Playfield) startReplay(dir string) {
+	r := &replayRecorder{queue: make(chan replayEntry, ReplayBuffer), done: make(chan struct{})}
+	go r.write(dir, p.ReplayKeep)
+	p.replay = r
+	p.rotateReplay()
+}
+
+// rotateReplay has the writer move on to a new file, which opens with a
+// header and the snapshot of the field as it stands. It waits for the queue
+// to have room for all of that, so a file never starts short.
+func (p *Playfield) rotateReplay() {
+	r := p.replay
+	snapshot := p.snapshotPackets()
+	if cap(r.queue)-len(r.queue) <= len(snapshot) {
+		return
+	}
+	if r.dropped > 0 {
+		log.Printf("Replay fell behind and dropped %d frames; starting a new file", r.dropped)
+	}
+	name := fmt.Sprintf("%s-%s-%d.jsonl",
+		unsafeFileChars.ReplaceAllString(p.Room, "_"), time.Now().UTC().Format("20060102-150405"), p.ticks)
+	r.queue <- replayEntry{name: name, header: &ReplayHeader{
+		Version:   1,
+		Room:      p.Room,
+		Seed:      p.Seed,
+		Started:   time.Now().UTC(),
+		FirstTick: p.ticks,
+		Tick:      p.Rules.Tick,
+		Foods:     p.Rules.Foods,
+		Portals:   p.portals,
+	}}
+	r.firstTick, r.lost, r.dropped = p.ticks, false, 0
+	for _, pkt := range snapshot {
+		p.recordPacket(pkt)
+	}
+}
+
+// stopReplay ends the recording, if any. The writer finishes the queued
+// frames and closes the file on its own.
+func (p *Playfield) stopReplay() {
+	if p.replay == nil {
+		return
+	}
+	close(p.replay.queue)
+	p.replay = nil
+}
+
+// write is the recording's writer goroutine. It runs until stopReplay
+// closes the queue. Once a file fails it is closed, and frames are
+// skipped until the next header.
+func (r *replayRecorder) write(dir string, keep int) {
+	defer close(r.done)
+	for e := range r.queue {
+		switch {
+		case e.header != nil:
+			r.finish()
+			if r.open(dir, e.name, *e.header) {
+				log.Printf("Recording replay %s", e.name)
+				pruneReplays(dir, keep)
+			}
+		case r.file == nil:
+		case e.flush:
+			if err := r.w.Flush(); err != nil {
+				r.fail(err)
+			}
+		default:
+			if err := r.enc.Encode(e.frame); err != nil {
+				r.fail(err)
+			}
+		}
+	}
+	r.finish()
+}
+
+// open creates the file name in dir and writes header to it.
+func (r *replayRecorder) open(dir, name string, header ReplayHeader) bool {
+	r.path = filepath.Join(dir, name)
+	f, err := os.Create(r.path)
+	if err != nil {
+		log.Printf("Replay not recorded: %v", err)
+		return false
+	}
+	r.file, r.w = f, bufio.NewWriter(f)
+	r.enc = json.NewEncoder(r.w)
+	err = r.enc.Encode(header)
+	if err == nil {
+		err = r.w.Flush()
+	}
+	if err != nil {
+		// Without its header the file can't be played; don't leave it.
+		log.Printf("Replay not recorded: %v", err)
+		f.Close()
+		os.Remove(r.path)
+		r.file = nil
+		return false
+	}
+	return true
+}
+
+func (r *replayRecorder) fail(err error) {
+	log.Printf("Replay write failed, %s stopped: %v", filepath.Base(r.path), err)
+	r.file.Close()
+	r.file = nil
+}
+
+// finish flushes and closes the current file, if any.
+func (r *replayRecorder) finish() {
+	if r.file == nil {
+		return
+	}
+	if err := r.w.Flush(); err != nil {
+		log.Printf("Replay write failed: %v", err)
+	}
+	r.file.Close()
+	r.file = nil
+}
+
+// pruneReplays deletes the oldest replay files in dir past the newest
+// keep.
+func pruneReplays(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("Listing replays: %v", err)
+		return
+	}
+	type replayFile struct {
+		name     string
+		modified time.Time
+	}
+	var files []replayFile
+	for _, e := range entries {
+		if !replayName.MatchString(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, replayFile{e.Name(), info.ModTime()})
+	}
+	if len(files) <= keep {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modified.After(files[j].modified) })
+	for _, f := range files[keep:] {
+		if err := os.Remove(filepath.Join(dir, f.name)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Deleting old replay: %v", err)
+		}
+	}
+}
+
+// recordPacket queues one broadcast for the replay, if recording. A full
+// queue drops it and marks the file lost.
+func (p *Playfield) recordPacket(pkt Packet) {
+	r := p.replay
+	if r == nil {
+		return
+	}
+	if !trySend(r.queue, replayEntry{frame: ReplayFrame{Tick: p.ticks, Packet: pkt}}) {
+		r.lost = true
+		r.dropped++
+	}
+}
+
+// flushReplay ends the tick's frames, and moves on to a new file once the
+// current one has run p.ReplayMaxTicks or lost a frame.
+func (p *Playfield) flushReplay() {
+	r := p.replay
+	if r == nil {
+		return
+	}
+	if r.lost || p.ticks-r.firstTick >= p.ReplayMaxTicks {
+		p.rotateReplay()
+		return
+	}
+	trySend(r.queue, replayEntry{flush: true})
+}
+
+// snapshotPackets are the broadcasts that draw the field as it is now:
+// every food, worm score and body, and Pac-Man.
+func (p *Playfield) snapshotPackets() []Packet {
+	var out []Packet
+	for _, f := range p.sortedFoods() {
+		out = append(out, p.foodPacket(*f))
+	}
+	for _, m := range p.sortedMovables() {
+		w, ok := m.(*Worm)
+		if !ok {
+			continue
+		}
+		id := p.Movables[m]
+		out = append(out, scorePacket(id, w))
+		if !w.killed {
+			out = append(out, Packet{Command: "MOVE", Payload: MovePayload{Id: id, Positions: w.Positions(), Protected: w.Protected()}})
+		}
+	}
+	for _, pm := range p.pacmen {
+		out = append(out, pacManPacket(pm))
+	}
+	return out
+}
+
+// replayName matches the files a spectator may ask for: no directories,
+// nothing but what startReplay writes.
+var replayName = regexp.MustCompile(`^[A-Za-z0-9_-]+\.jsonl$`)
+
+// ReplayInfo describes one recorded file in the GET /replays listing.
+type ReplayInfo struct {
+	Name    string
+	Room    string
+	Seed    uint64
+	Started time.Time
+}
+
+// ReplaysHandler lists the recorded replays, newest first, as JSON.
+func ReplaysHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dir := currentReplayDir()
+		if dir == "" {
+			http.Error(w, "replays are not recorded on this server", http.StatusNotFound)
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			http.Error(w, "cannot list replays", http.StatusInternalServerError)
+			return
+		}
+		list := []ReplayInfo{}
+		for _, e := range entries {
+			if !replayName.MatchString(e.Name()) {
+				continue
+			}
+			header, err := readReplayHeader(filepath.Join(dir, e.Name()))
+			if err != nil {
+				continue
+			}
+			list = append(list, ReplayInfo{Name: e.Name(), Room: header.Room, Seed: header.Seed, Started: header.Started})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Started.After(list[j].Started) })
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	})
+}
+
+func readReplayHeader(path string) (ReplayHeader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ReplayHeader{}, err
+	}
+	defer f.Close()
+	var h ReplayHeader
+	err = json.NewDecoder(f).Decode(&h)
+	return h, err
+}
+
+// replayFile is a replay loaded for playback: the header, and the frames
+// still encoded, grouped by tick in order.
+type replayFile struct {
+	header ReplayHeader
+	ticks  []replayTick
+}
+
+type replayTick struct {
+	tick    int
+	packets []json.RawMessage
+}
+
+func loadReplay(path string) (*replayFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	rf := &replayFile{}
+	if err := dec.Decode(&rf.header); err != nil {
+		return nil, fmt.Errorf("replay header: %w", err)
+	}
+	for {
+		var frame struct {
+			Tick   int
+			Packet json.RawMessage
+		}
+		if err := dec.Decode(&frame); err != nil {
+			// The end, or a file still being written that stops
+			// mid-line: play what's there.
+			break
+		}
+		n := len(rf.ticks)
+		if n == 0 || rf.ticks[n-1].tick != frame.Tick {
+			rf.ticks = append(rf.ticks, replayTick{tick: frame.Tick})
+			n++
+		}
+		rf.ticks[n-1].packets = append(rf.ticks[n-1].packets, frame.Packet)
+	}
+	return rf, nil
+}
+
+// ReplayControl is what a spectator sends to steer playback: SPEED with
+// one of ReplaySpeeds, SEEK with a tick, or PAUSE with true or false.
+type ReplayControl struct {
+	Command string
+	Payload json.RawMessage
+}
+
+// ReplayWatchHandler streams /replays/{name}/watch to a spectator over a
+// websocket. It opens with REPLAY (ReplayPayload), then sends the recorded
+// packets tick by tick at the chosen speed, each tick preceded by
+// REPLAY_TICK. A SEEK is answered with RESET and an instant catch-up to
+// the wanted tick.
+func ReplayWatchHandler() http.Handler {
+	return &websocket.Server{
+		Handshake: checkOrigin,
+		Handler:   watchReplay,
+	}
+}
+
+func watchReplay(ws *websocket.Conn) {
+	defer ws.Close()
+	release, err := addrSlot(ws.Request().RemoteAddr)
+	if err != nil {
+		log.Printf("Rejecting spectator from %s: %v", ws.Request().RemoteAddr, err)
+		return
+	}
+	defer release()
+
+	dir, name := currentReplayDir(), ws.Request().PathValue("name")
+	if dir == "" || !replayName.MatchString(name) {
+		return
+	}
+	rf, err := loadReplay(filepath.Join(dir, name))
+	if err != nil {
+		log.Printf("Cannot play replay %s: %v", name, err)
+		return
+	}
+	if len(rf.ticks) == 0 {
+		return
+	}
+
+	controls := make(chan ReplayControl, 8)
+	go func() {
+		defer close(controls)
+		for {
+			var c ReplayControl
+			if err := websocket.JSON.Receive(ws, &c); err != nil {
+				return
+			}
+			trySend(controls, c)
+		}
+	}()
+
+	send := func(pkt Packet) bool { return websocket.JSON.Send(ws, pkt) == nil }
+	sendRaw := func(raw json.RawMessage) bool { return websocket.Message.Send(ws, string(raw)) == nil }
+	first, last := rf.ticks[0].tick, rf.ticks[len(rf.ticks)-1].tick
+	if !send(Packet{Command: "REPLAY", Payload: ReplayPayload{
+		Name:      name,
+		Room:      rf.header.Room,
+		Seed:      rf.header.Seed,
+		Started:   rf.header.Started,
+		FirstTick: first,
+		LastTick:  last,
+		Speeds:    ReplaySpeeds,
+		Foods:     rf.header.Foods,
+		Tick:      rf.header.Tick,
+	}}) || !send(Packet{Command: "PORTALS", Payload: PortalsPayload{Portals: rf.header.Portals}}) {
+		return
+	}
+
+	speed, paused, pos := 1, false, 0
+	tickLength := time.Duration(rf.header.Tick) * time.Millisecond
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case c, ok := <-controls:
+			if !ok {
+				return
+			}
+			switch c.Command {
+			case "SPEED":
+				var s int
+				if json.Unmarshal(c.Payload, &s) == nil {
+					for _, allowed := range ReplaySpeeds {
+						if s == allowed {
+							speed = s
+						}
+					}
+				}
+			case "PAUSE":
+				json.Unmarshal(c.Payload, &paused)
+				if !paused {
+					timer.Reset(0)
+				}
+			case "SEEK":
+				var to int
+				if json.Unmarshal(c.Payload, &to) != nil {
+					break
+				}
+				if !send(Packet{Command: "RESET"}) {
+					return
+				}
+				// Everything before the wanted tick goes out at once so
+				// the field is rebuilt, then playback carries on.
+				pos = 0
+				for pos < len(rf.ticks) && rf.ticks[pos].tick < to {
+					for _, raw := range rf.ticks[pos].packets {
+						if !sendRaw(raw) {
+							return
+						}
+					}
+					pos++
+				}
+				timer.Reset(0)
+			}
+		case <-timer.C:
+			if paused || pos >= len(rf.ticks) {
+				break
+			}
+			t := rf.ticks[pos]
+			if !send(Packet{Command: "REPLAY_TICK", Payload: t.tick}) {
+				return
+			}
+			for _, raw := range t.packets {
+				if !sendRaw(raw) {
+					return
+				}
+			}
+			pos++
+			if pos < len(rf.ticks) {
+				// Idle ticks recorded nothing; wait them out too.
+				gap := rf.ticks[pos].tick - t.tick
+				timer.Reset(time.Duration(gap) * tickLength / time.Duration(speed))
+			}
+		}
+	}
+}
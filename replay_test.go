@@ -0,0 +1,246 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// recordTicks runs n ticks of p the way Start does, recording as it goes.
+func recordTicks(p *Playfield, n int) {
+	for i := 0; i < n; i++ {
+		p.drainBroadcast()
+		p.flushReplay()
+		p.tick()
+	}
+	p.drainBroadcast()
+	p.flushReplay()
+}
+
+// finishReplay stops p's recording and waits for its writer to close the
+// file.
+func finishReplay(p *Playfield) {
+	r := p.replay
+	p.stopReplay()
+	<-r.done
+}
+
+func replayFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestReplayRecordsHeaderSnapshotAndTicks(t *testing.T) {
+	dir := t.TempDir()
+	p := NewSeededPlayfield(7)
+	p.Room = "lobby 1"
+	w := addWormAt(p, Position{X: 10, Y: 10})
+	p.startReplay(dir)
+	recordTicks(p, 5)
+	finishReplay(p)
+
+	names := replayFiles(t, dir)
+	if len(names) != 1 || !replayName.MatchString(names[0]) || !strings.HasPrefix(names[0], "lobby_1-") {
+		t.Fatalf("Expected one safely named replay file, got %v", names)
+	}
+	rf, err := loadReplay(dir + "/" + names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.header.Seed != 7 || rf.header.Room != "lobby 1" || rf.header.Tick != Tick {
+		t.Errorf("Unexpected header: %+v", rf.header)
+	}
+	if len(rf.ticks) < 2 || rf.ticks[0].tick != 0 {
+		t.Fatalf("Expected frames grouped from tick 0 on, got %d groups", len(rf.ticks))
+	}
+	var first Packet
+	json.Unmarshal(rf.ticks[0].packets[0], &first)
+	if first.Command != "SCORE" {
+		t.Errorf("Expected the snapshot to open with the worm's SCORE, got %s", first.Command)
+	}
+	for i := 1; i < len(rf.ticks); i++ {
+		if rf.ticks[i].tick <= rf.ticks[i-1].tick {
+			t.Fatalf("Ticks out of order: %d after %d", rf.ticks[i].tick, rf.ticks[i-1].tick)
+		}
+	}
+	last := rf.ticks[len(rf.ticks)-1]
+	var move struct {
+		Command string
+		Payload MovePayload
+	}
+	found := false
+	for _, raw := range last.packets {
+		json.Unmarshal(raw, &move)
+		if move.Command == "MOVE" && move.Payload.Id == p.Movables[w] {
+			found = move.Payload.Positions[0] == w.Head()
+		}
+	}
+	if !found {
+		t.Error("Expected the last tick to carry the worm's current MOVE")
+	}
+}
+
+func TestReplayRotatesWithSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	p := NewSeededPlayfield(7)
+	addWormAt(p, Position{X: 10, Y: 10})
+	p.startReplay(dir)
+	recordTicks(p, 2)
+	p.ticks += ReplayMaxTicks
+	p.flushReplay()
+	p.flushReplay()
+	finishReplay(p)
+
+	names := replayFiles(t, dir)
+	if len(names) != 2 {
+		t.Fatalf("Expected a second file after ReplayMaxTicks, got %v", names)
+	}
+	for _, name := range names {
+		rf, err := loadReplay(dir + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rf.ticks) == 0 || rf.ticks[0].tick != rf.header.FirstTick {
+			t.Errorf("Expected %s to open with a snapshot at its first tick", name)
+		}
+	}
+}
+
+func TestReplayRecordsOnlyDuringRounds(t *testing.T) {
+	dir := t.TempDir()
+	p := NewSeededPlayfield(7)
+	p.ReplayDir = dir
+	w := addWormAt(p, Position{X: 10, Y: 10})
+	recordTicks(p, 3)
+	if names := replayFiles(t, dir); len(names) != 0 {
+		t.Fatalf("Expected nothing recorded without a human online, got %v", names)
+	}
+
+	w.connected = true
+	recordTicks(p, 3)
+	r := p.replay
+	if r == nil {
+		t.Fatal("Expected a replay once a human is online")
+	}
+	w.connected = false
+	recordTicks(p, 3)
+	if p.replay != nil {
+		t.Fatal("Expected recording to stop with the round")
+	}
+	<-r.done
+	if len(replayFiles(t, dir)) != 1 {
+		t.Errorf("Expected recording to stop with the round, got %v", replayFiles(t, dir))
+	}
+}
+
+func TestReplayDropsAndStartsOverWhenWriterIsBehind(t *testing.T) {
+	p := NewSeededPlayfield(7)
+	addWormAt(p, Position{X: 10, Y: 10})
+	// No writer goroutine: the queue fills and stays full.
+	r := &replayRecorder{queue: make(chan replayEntry, len(p.snapshotPackets())+1)}
+	p.replay = r
+	p.rotateReplay()
+	p.recordPacket(Packet{Command: "MOVE"})
+	if !r.lost || r.dropped != 1 {
+		t.Fatalf("Expected a frame dropped into a full queue, lost %v dropped %d", r.lost, r.dropped)
+	}
+	p.ticks++
+	p.flushReplay()
+	if !r.lost {
+		t.Fatal("Expected no new file without room for its snapshot")
+	}
+	for len(r.queue) > 0 {
+		<-r.queue
+	}
+	p.flushReplay()
+	if e := <-r.queue; r.lost || e.header == nil || e.header.FirstTick != 1 {
+		t.Errorf("Expected a new file once the writer caught up, got %+v", e)
+	}
+}
+
+func TestPruneReplaysKeepsTheNewest(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	for i, name := range []string{"a-1.jsonl", "b-2.jsonl", "c-3.jsonl", "d-4.jsonl"} {
+		path := dir + "/" + name
+		os.WriteFile(path, nil, 0o644)
+		at := old.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(path, at, at)
+	}
+	os.WriteFile(dir+"/notes.txt", nil, 0o644)
+
+	pruneReplays(dir, 2)
+	names := replayFiles(t, dir)
+	if strings.Join(names, ",") != "c-3.jsonl,d-4.jsonl,notes.txt" {
+		t.Errorf("Expected the two newest replays and other files kept, got %v", names)
+	}
+}
+
+func TestReplaysListedAndWatched(t *testing.T) {
+	dir := t.TempDir()
+	p := NewSeededPlayfield(7)
+	p.Room = "1"
+	addWormAt(p, Position{X: 10, Y: 10})
+	p.startReplay(dir)
+	recordTicks(p, 3)
+	finishReplay(p)
+	os.WriteFile(dir+"/notes.txt", []byte("not a replay"), 0o644)
+	SetReplayDir(dir)
+	defer SetReplayDir("")
+
+	rec := httptest.NewRecorder()
+	ReplaysHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/replays", nil))
+	var list []ReplayInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].Seed != 7 {
+		t.Fatalf("Expected the one replay listed, got %q", rec.Body.String())
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/replays/{name}/watch", ReplayWatchHandler())
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/replays/" + list[0].Name + "/watch"
+	conn, err := websocket.Dial(url, "", "http://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var opening struct {
+		Command string
+		Payload ReplayPayload
+	}
+	if err := websocket.JSON.Receive(conn, &opening); err != nil || opening.Command != "REPLAY" {
+		t.Fatalf("Expected REPLAY first, got %+v (%v)", opening, err)
+	}
+	if opening.Payload.Room != "1" || opening.Payload.LastTick <= opening.Payload.FirstTick {
+		t.Errorf("Unexpected REPLAY payload: %+v", opening.Payload)
+	}
+	websocket.JSON.Send(conn, ReplayControl{Command: "SPEED", Payload: json.RawMessage("4")})
+	websocket.JSON.Send(conn, ReplayControl{Command: "SEEK", Payload: json.RawMessage("2")})
+	seen := map[string]bool{}
+	for !seen["RESET"] || !seen["MOVE"] {
+		var pkt Packet
+		if err := websocket.JSON.Receive(conn, &pkt); err != nil {
+			t.Fatalf("Expected PORTALS, RESET and MOVE packets, saw %v: %v", seen, err)
+		}
+		seen[pkt.Command] = true
+	}
+	if !seen["PORTALS"] {
+		t.Error("Expected the portals before the first tick")
+	}
+}
@@ -0,0 +1,162 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Rules switches optional mechanics on or off for a single playfield and
+// carries the data it plays with, such as the food catalogue.
+// NewPlayfield starts from DefaultRules; callers may adjust p.Rules before
+// Start, after which only the playfield goroutine reads it.
+type Rules struct {
+	// Tick is how long one tick lasts, in milliseconds, and DisconnectTTL
+	// how long a human's worm stays after its websocket drops.
+	Tick          int
+	DisconnectTTL Duration
+
+	// MinPlayers is what AutoBots tops the room up to with bots.
+	// BotMoveDeadline is how long a bot API client has to answer a STATE.
+	MinPlayers      int
+	BotMoveDeadline Duration
+
+	// FoodCount is how many foods are kept on the field, and RemainsEvery
+	// the body-cell stride at which a dead worm leaves remains.
+	FoodCount    int
+	RemainsEvery int
+
+	// Combo is the eating streak tuning.
+	Combo Combo
+
+	// HumansPerPacMan connected humans get a Pac-Man each, up to
+	// MaxPacMen. A power pellet frightens them for PacManFrightenedTicks;
+	// eating one is worth PacManEatPoints and keeps him away for
+	// PacManRespawnDelay ticks.
+	HumansPerPacMan       int
+	MaxPacMen             int
+	PacManFrightenedTicks int
+	PacManEatPoints       int
+	PacManRespawnDelay    int
+
+	// BiteRemains turns the segments Pac-Man bites off a body into
+	// remains, the same way a dead worm's whole body is.
+	BiteRemains bool
+
+	// KillPoints is credited to a worm whose body another worm crashed
+	// into. Zero disables kill credit.
+	KillPoints int
+
+	// RespawnCooldown is the minimum number of ticks between a death (or
+	// the previous respawn) and the next respawn, so RESPAWN can't be
+	// spammed.
+	RespawnCooldown int
+
+	// SpawnProtection is how many ticks a freshly (re)spawned worm is
+	// shielded from Pac-Man and from body contact with other worms.
+	SpawnProtection int
+
+	// PortalPairs is how many portal pairs Start places on the field.
+	PortalPairs int
+
+	// Difficulty is the preset the room's bots are drawn at. With
+	// AdaptiveDifficulty on it is only the starting point: the level then
+	// follows the best human's score (see adaptiveLevels).
+	Difficulty         Difficulty
+	AdaptiveDifficulty bool
+
+	// BotMix splits the room's bots between registered strategies.
+	BotMix BotMix
+
+	// Personas are tuned bots handed out before any is drawn from the
+	// Difficulty preset. Empty by default.
+	Personas Personas
+
+	// Bots is how many AI bots the room keeps while a human is online;
+	// AutoBots tops it up to MinPlayers instead. NoPacMan keeps Pac-Men
+	// off the field.
+	Bots     int
+	NoPacMan bool
+
+	// Foods is the catalogue spawnFood rolls from. It is shared, not
+	// copied, between playfields, so treat it as read-only once set.
+	Foods FoodCatalogue
+}
+
+// DefaultRules is what every lobby playfield runs with.
+func DefaultRules() Rules {
+	return Rules{
+		Tick:                  Tick,
+		DisconnectTTL:         Duration(DisconnectTTL),
+		MinPlayers:            MinPlayers,
+		BotMoveDeadline:       Duration(BotMoveDeadline),
+		FoodCount:             FoodCount,
+		RemainsEvery:          RemainsEvery,
+		Combo:                 Combo{Window: ComboWindow, MaxMultiplier: MaxMultiplier},
+		HumansPerPacMan:       HumansPerPacMan,
+		MaxPacMen:             MaxPacMen,
+		PacManFrightenedTicks: PacManFrightenedTicks,
+		PacManEatPoints:       PacManEatPoints,
+		PacManRespawnDelay:    PacManRespawnDelay,
+		KillPoints:            20,
+		RespawnCooldown:       10, // 2s at the default Tick
+		SpawnProtection:       15, // 3s
+		PortalPairs:           2,
+		Difficulty:            Normal,
+		BotMix:                BotMix{{Strategy: DefaultStrategy, Weight: 1}},
+		Bots:                  AutoBots,
+		Foods:                 DefaultFoodCatalogue(),
+	}
+}
+
+// Validate rejects rules a playfield can't run with: an unknown difficulty,
+// a tick or deadline out of range, negative counts, a bot setting outside
+// AutoBots..MaxBots, and invalid bot mixes, personas or food catalogues.
+func (r Rules) Validate() error {
+	if _, err := ParseDifficulty(string(r.Difficulty)); err != nil {
+		return err
+	}
+	if r.Tick < MinTick || r.Tick > MaxTick {
+		return fmt.Errorf("Tick must be %d to %d milliseconds", MinTick, MaxTick)
+	}
+	if r.DisconnectTTL <= 0 {
+		return errors.New("DisconnectTTL must be positive")
+	}
+	if r.BotMoveDeadline <= 0 || time.Duration(r.BotMoveDeadline) >= time.Duration(r.Tick)*time.Millisecond {
+		return errors.New("BotMoveDeadline must be positive and shorter than a Tick")
+	}
+	if r.MinPlayers < 0 || r.MinPlayers > MaxBots+1 {
+		return fmt.Errorf("MinPlayers must be 0 to %d", MaxBots+1)
+	}
+	if r.FoodCount < 1 || r.RemainsEvery < 1 {
+		return errors.New("FoodCount and RemainsEvery must be at least 1")
+	}
+	if err := r.Combo.Validate(); err != nil {
+		return err
+	}
+	if r.HumansPerPacMan < 1 {
+		return errors.New("HumansPerPacMan must be at least 1")
+	}
+	if r.MaxPacMen < 0 || r.MaxPacMen > len(pacManKinds) {
+		return fmt.Errorf("MaxPacMen must be 0 to %d", len(pacManKinds))
+	}
+	if r.PacManFrightenedTicks < 0 || r.PacManEatPoints < 0 || r.PacManRespawnDelay < 0 {
+		return errors.New("PacManFrightenedTicks, PacManEatPoints and PacManRespawnDelay must not be negative")
+	}
+	if r.KillPoints < 0 || r.RespawnCooldown < 0 || r.SpawnProtection < 0 || r.PortalPairs < 0 {
+		return errors.New("KillPoints, RespawnCooldown, SpawnProtection and PortalPairs must not be negative")
+	}
+	if r.PortalPairs > MaxPortalPairs {
+		return fmt.Errorf("at most %d PortalPairs", MaxPortalPairs)
+	}
+	if r.Bots < AutoBots || r.Bots > MaxBots {
+		return fmt.Errorf("Bots must be %d (automatic) to %d", AutoBots, MaxBots)
+	}
+	if err := r.BotMix.Validate(); err != nil {
+		return err
+	}
+	if err := r.Personas.Validate(); err != nil {
+		return err
+	}
+	return r.Foods.Validate()
+}
@@ -11,31 +11,40 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/websocket"
 )
 
 // Limits applied to inbound traffic. The per-IP and total caps are tunable
-// via FLOW_MAX_CONNS_PER_IP and FLOW_MAX_CONNS so a deployer can dial them up
-// or down without recompiling. Defaults err on the lenient side because
-// shared NATs / classroom IPs are common.
+// via FLOW_MAX_CONNS_PER_IP and FLOW_MAX_CONNS (or a config file, see
+// SetLimits) so a deployer can dial them up or down without recompiling.
+// Defaults err on the lenient side because shared NATs / classroom IPs are
+// common.
 const (
 	maxNameLength       = 32              // characters; longer names are truncated
 	maxCommandLogLength = 32              // bytes of message.Command surfaced in logs
 	helloDeadline       = 5 * time.Second // time to send the first HELLO
 	readIdleDeadline    = 90 * time.Second
+
+	defaultMaxConnsPerIP = 32
+	defaultMaxConns      = 256
 )
 
+// connCountsMu guards the connection counts and, since SetLimits may
+// replace them, the limits and origin allowlist below.
 var (
 	connCountsMu sync.Mutex
 	connCounts   = map[string]int{}
 	connTotal    int
+	// connRejected counts addrSlot refusals by reason, for /metrics.
+	connRejected = map[string]int{}
 
-	perIPConnLimit = intEnv("FLOW_MAX_CONNS_PER_IP", 32)
-	totalConnLimit = intEnv("FLOW_MAX_CONNS", 256)
+	perIPConnLimit = intEnv("FLOW_MAX_CONNS_PER_IP", defaultMaxConnsPerIP)
+	totalConnLimit = intEnv("FLOW_MAX_CONNS", defaultMaxConns)
 
-	allowedOrigins  = parseAllowedOrigins(os.Getenv("FLOW_ALLOWED_ORIGINS"))
+	allowedOrigins  = SplitList(os.Getenv("FLOW_ALLOWED_ORIGINS"))
 	allowAllOrigins = len(allowedOrigins) == 0
 )
 
@@ -48,7 +57,9 @@ func intEnv(name string, def int) int {
 	return def
 }
 
-func parseAllowedOrigins(raw string) []string {
+// SplitList parses a comma-separated list, such as an env var or flag,
+// dropping blanks.
+func SplitList(raw string) []string {
 	if raw == "" {
 		return nil
 	}
@@ -62,18 +73,31 @@ func parseAllowedOrigins(raw string) []string {
 	return out
 }
 
+// SetLimits replaces the connection limits and origin allowlist read from
+// the environment at startup. Call it before serving.
+func SetLimits(l Limits) {
+	connCountsMu.Lock()
+	defer connCountsMu.Unlock()
+	perIPConnLimit, totalConnLimit = l.MaxConnsPerIP, l.MaxConns
+	allowedOrigins = l.AllowedOrigins
+	allowAllOrigins = len(allowedOrigins) == 0
+}
+
 // checkOrigin enforces the FLOW_ALLOWED_ORIGINS allowlist when set. The default
 // (env var unset) permits any origin so dev / single-player local usage stays
 // frictionless; production should set the env var to the public site.
 func checkOrigin(config *websocket.Config, req *http.Request) error {
 	got := req.Header.Get("Origin")
-	if allowAllOrigins {
+	connCountsMu.Lock()
+	all, origins := allowAllOrigins, allowedOrigins
+	connCountsMu.Unlock()
+	if all {
 		return nil
 	}
 	if got == "" {
 		return errors.New("websocket: missing Origin")
 	}
-	for _, allowed := range allowedOrigins {
+	for _, allowed := range origins {
 		if got == allowed {
 			return nil
 		}
@@ -92,9 +116,11 @@ func addrSlot(remote string) (func(), error) {
 	connCountsMu.Lock()
 	defer connCountsMu.Unlock()
 	if connTotal >= totalConnLimit {
+		connRejected["server_full"]++
 		return nil, errors.New("server full")
 	}
 	if connCounts[ip] >= perIPConnLimit {
+		connRejected["per_address"]++
 		return nil, errors.New("too many connections from this address")
 	}
 	connCounts[ip]++
@@ -180,6 +206,17 @@ func WormsServer(ws *websocket.Conn) {
 	_ = ws.SetReadDeadline(time.Time{}) // back to no overall deadline
 
 	name, token := extractHello(hello)
+	// A HELLO with a BotKey asks for the bot API: per-tick STATE frames and
+	// a MOVE deadline. A wrong key is refused outright rather than quietly
+	// downgraded to a human seat.
+	bot := false
+	if key := extractBotKey(hello); key != "" {
+		if !validBotKey(key) {
+			log.Printf("Refusing bot connection from %s: bad key", ws.Request().RemoteAddr)
+			return
+		}
+		bot = true
+	}
 	// Server-managed AI tokens are never accepted from a client. Replace any
 	// such claim with a fresh random token so the connection still works.
 	if token == "" || isAIToken(token) {
@@ -189,9 +226,13 @@ func WormsServer(ws *websocket.Conn) {
 		log.Printf("Refusing connection: token generation failed")
 		return
 	}
+	if banned(token) {
+		log.Printf("Refusing connection from %s: banned token", ws.Request().RemoteAddr)
+		return
+	}
 
 	reply := make(chan AttachReply, 1)
-	playfield.Attach <- AttachRequest{Token: token, Name: name, Reply: reply}
+	playfield.Attach <- AttachRequest{Token: token, Name: name, Bot: bot, Reply: reply}
 	attached := <-reply
 	worm := attached.Worm
 
@@ -206,6 +247,10 @@ func WormsServer(ws *websocket.Conn) {
 	}
 
 	quit := make(chan struct{})
+	// stateDue is when the answer to the STATE frame the transmit
+	// goroutine last sent a bot is due (unix nanos), going by the frame's
+	// Deadline, or zero once that frame has been answered.
+	var stateDue atomic.Int64
 
 	// Receive from client
 	go func() {
@@ -235,6 +280,13 @@ func WormsServer(ws *websocket.Conn) {
 			case "RESPAWN":
 				trySend(playfield.Respawn, RespawnRequest{Worm: worm})
 			case "MOVE":
+				if bot {
+					// One answer per STATE, inside its deadline.
+					due := stateDue.Swap(0)
+					if due == 0 || time.Now().UnixNano() > due {
+						break
+					}
+				}
 				if d, ok := parseMoveDirection(message.Payload); ok {
 					trySend(playfield.MoveCmd, DirectionRequest{Worm: worm, Direction: d})
 				}
@@ -248,6 +300,10 @@ func WormsServer(ws *websocket.Conn) {
 	go func() {
 		defer close(quit)
 		for message := range worm.Outbox {
+			if state, ok := message.Payload.(StatePayload); ok {
+				deadline := time.Duration(state.Deadline) * time.Millisecond
+				stateDue.Store(time.Now().Add(deadline).UnixNano())
+			}
 			if err := websocket.JSON.Send(ws, message); err != nil {
 				log.Printf("Error sending packet: %v", err)
 				return
@@ -301,6 +357,16 @@ func extractHello(pkt Packet) (name, token string) {
 	return
 }
 
+// extractBotKey pulls the optional BotKey out of a HELLO payload.
+func extractBotKey(pkt Packet) string {
+	if p, ok := pkt.Payload.(map[string]interface{}); ok {
+		if k, ok := p["BotKey"].(string); ok {
+			return k
+		}
+	}
+	return ""
+}
+
 // WormsHandler returns an http.Handler that performs the websocket upgrade
 // with origin enforcement. FLOW_ALLOWED_ORIGINS (comma-separated) restricts
 // the allowed Origin headers; unset means any origin (intended for local dev
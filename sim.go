@@ -0,0 +1,242 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// SimConfig describes a headless match run by Simulate: no websockets, no
+// ticker, just the playfield loop stepped Ticks times as fast as it goes.
+type SimConfig struct {
+	Seed  uint64
+	Ticks int
+	// Bots is how many AI bots join, regardless of MinPlayers.
+	Bots int
+	// Humans is how many simulated humans join. They count as online
+	// players — bots and Pac-Men only run while one is — and are steered
+	// by HumanStrategy (DefaultStrategy if empty).
+	Humans        int
+	HumanStrategy string
+	// Rules is used as is; start from DefaultRules.
+	Rules Rules
+}
+
+// MaxSimHumans caps SimConfig.Humans. A joining worm is caught up on
+// every worm already in the room before anything reads its Outbox, so the
+// room has to fit in one.
+const MaxSimHumans = 16
+
+// Validate rejects a config Simulate can't run: no human, or more humans
+// or bots than fit in a room.
+func (cfg SimConfig) Validate() error {
+	if cfg.Humans < 1 {
+		return errors.New("need at least one simulated human: bots and Pac-Men only run while a human is online")
+	}
+	if cfg.Humans > MaxSimHumans {
+		return fmt.Errorf("at most %d simulated humans", MaxSimHumans)
+	}
+	if cfg.Bots < 0 || cfg.Bots > MaxBots {
+		return fmt.Errorf("Bots must be 0 to %d", MaxBots)
+	}
+	return nil
+}
+
+// SimStats is what happened over one or more Simulate runs. Lifespans and
+// Scores cover completed lives only: worms still alive when the run ends
+// are left out, so the cut-off doesn't skew the averages.
+type SimStats struct {
+	Ticks       int
+	Deaths      map[DeathCause]int
+	Lifespans   []int // ticks lived, one per completed life
+	Scores      []int // score at death, one per completed life
+	PacManBites int   // body bites plus fatal head bites
+	// Bots is each AI bot's own record, keyed by name.
+	Bots map[string]BotStats
+}
+
+// BotStats is one bot's record in SimStats.Bots.
+type BotStats struct {
+	Matches    int // simulations it played in
+	Deaths     int
+	TicksAlive int
+	Points     int // scored over all its lives, the unfinished last one included
+}
+
+// Lives is the number of completed lives.
+func (s SimStats) Lives() int { return len(s.Lifespans) }
+
+// AverageLifespan is the mean number of ticks a life lasted.
+func (s SimStats) AverageLifespan() float64 {
+	if len(s.Lifespans) == 0 {
+		return 0
+	}
+	total := 0
+	for _, l := range s.Lifespans {
+		total += l
+	}
+	return float64(total) / float64(len(s.Lifespans))
+}
+
+// ScorePercentile is the score below which fraction q (0..1) of completed
+// lives ended.
+func (s SimStats) ScorePercentile(q float64) int {
+	if len(s.Scores) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), s.Scores...)
+	sort.Ints(sorted)
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+// BiteRate is Pac-Man bites per 1000 ticks.
+func (s SimStats) BiteRate() float64 {
+	if s.Ticks == 0 {
+		return 0
+	}
+	return float64(s.PacManBites) * 1000 / float64(s.Ticks)
+}
+
+// Add folds o into s, for aggregating several seeds.
+func (s *SimStats) Add(o SimStats) {
+	if s.Deaths == nil {
+		s.Deaths = map[DeathCause]int{}
+	}
+	if s.Bots == nil {
+		s.Bots = map[string]BotStats{}
+	}
+	s.Ticks += o.Ticks
+	for c, n := range o.Deaths {
+		s.Deaths[c] += n
+	}
+	s.Lifespans = append(s.Lifespans, o.Lifespans...)
+	s.Scores = append(s.Scores, o.Scores...)
+	s.PacManBites += o.PacManBites
+	for name, b := range o.Bots {
+		t := s.Bots[name]
+		t.Matches += b.Matches
+		t.Deaths += b.Deaths
+		t.TicksAlive += b.TicksAlive
+		t.Points += b.Points
+		s.Bots[name] = t
+	}
+}
+
+// Simulate plays cfg out on a fresh playfield seeded with cfg.Seed and
+// reports what happened. The same config always yields the same stats.
+func Simulate(cfg SimConfig) (SimStats, error) {
+	if err := cfg.Validate(); err != nil {
+		return SimStats{}, err
+	}
+	name := cfg.HumanStrategy
+	if name == "" {
+		name = DefaultStrategy
+	}
+	strategy, ok := LookupStrategy(name)
+	if !ok {
+		return SimStats{}, fmt.Errorf("unknown strategy %q", name)
+	}
+
+	p := NewSeededPlayfield(cfg.Seed)
+	p.Ticker.Stop()
+	p.Rules = cfg.Rules
+	p.placePortals(p.Rules.PortalPairs)
+
+	stats := SimStats{Deaths: map[DeathCause]int{}, Bots: map[string]BotStats{}}
+	var humans []*Worm
+	// drain stands in for the broadcast layer and the clients: nothing
+	// reads the playfield's channels otherwise, and a full one blocks it.
+	drain := func() {
+		for drained := false; !drained; {
+			select {
+			case pkt := <-p.Broadcast:
+				if bite, ok := pkt.Payload.(BitePayload); ok && bite.PacManId != 0 {
+					stats.PacManBites++
+				}
+			default:
+				drained = true
+			}
+		}
+		for _, w := range humans {
+			for len(w.Outbox) > 0 {
+				<-w.Outbox
+			}
+		}
+	}
+	for i := 0; i < cfg.Humans; i++ {
+		w := NewWorm()
+		w.Name = fmt.Sprintf("Human-%d", i+1)
+		w.Token = w.Name
+		w.connected = true
+		w.personality = newPersonality(p.rng, p.difficulty())
+		placeAt(w, p.safeSpawn())
+		w.protection = p.Rules.SpawnProtection
+		p.Tokens[w.Token] = w
+		humans = append(humans, w)
+		p.announceJoin(w, p.addMovable(w))
+		drain()
+	}
+	for i := 0; i < cfg.Bots; i++ {
+		p.spawnAI()
+		drain()
+	}
+	p.reconcilePacMan()
+	drain()
+
+	born := map[*Worm]int{}
+	dead := map[*Worm]bool{}
+	for i := 0; i < cfg.Ticks; i++ {
+		for _, w := range humans {
+			if w.killed {
+				// Press RESPAWN straight away; the tick honours the
+				// cooldown.
+				w.respawnPending = true
+				continue
+			}
+			w.inputs = []Direction{strategy.Decide(&View{w: w, p: p})}
+		}
+		p.tick()
+		stats.Ticks++
+		drain()
+
+		for _, m := range p.sortedMovables() {
+			w, ok := m.(*Worm)
+			if !ok {
+				continue
+			}
+			bot := stats.Bots[w.Name]
+			switch {
+			case w.killed && !dead[w]:
+				dead[w] = true
+				stats.Deaths[w.deathCause]++
+				if w.deathCause == CausePacMan {
+					stats.PacManBites++
+				}
+				stats.Lifespans = append(stats.Lifespans, p.ticks-born[w])
+				stats.Scores = append(stats.Scores, w.Score)
+				bot.Deaths++
+				bot.Points += w.Score
+			case !w.killed && dead[w]:
+				dead[w] = false
+				born[w] = p.ticks
+			}
+			if !w.killed {
+				bot.TicksAlive++
+			}
+			if w.AI {
+				stats.Bots[w.Name] = bot
+			}
+		}
+	}
+	for _, m := range p.sortedMovables() {
+		if w, ok := m.(*Worm); ok && w.AI {
+			bot := stats.Bots[w.Name]
+			bot.Matches++
+			if !w.killed {
+				bot.Points += w.Score
+			}
+			stats.Bots[w.Name] = bot
+		}
+	}
+	return stats, nil
+}
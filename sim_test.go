@@ -0,0 +1,63 @@
+package flow
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSimulateIsReproducible(t *testing.T) {
+	cfg := SimConfig{Seed: 3, Ticks: 500, Bots: 3, Humans: 1, Rules: DefaultRules()}
+	a, err := Simulate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Simulate(cfg)
+	if fmt.Sprint(a) != fmt.Sprint(b) {
+		t.Errorf("Same config gave different stats:\n%+v\n%+v", a, b)
+	}
+	if a.Ticks != 500 {
+		t.Errorf("Expected 500 ticks, got %d", a.Ticks)
+	}
+	if a.Lives() == 0 {
+		t.Error("Expected somebody to die in 500 ticks")
+	}
+	deaths := 0
+	for _, n := range a.Deaths {
+		deaths += n
+	}
+	if deaths != a.Lives() || len(a.Scores) != a.Lives() {
+		t.Errorf("Deaths %d, scores %d and lives %d should agree", deaths, len(a.Scores), a.Lives())
+	}
+}
+
+func TestSimulateFullRoom(t *testing.T) {
+	cfg := SimConfig{Seed: 5, Ticks: 50, Bots: MaxBots, Humans: MaxSimHumans, Rules: DefaultRules()}
+	if _, err := Simulate(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Humans = 1000
+	if _, err := Simulate(cfg); err == nil {
+		t.Error("Expected more humans than fit in a room refused")
+	}
+}
+
+func TestSimulateNeedsAHuman(t *testing.T) {
+	if _, err := Simulate(SimConfig{Ticks: 10, Bots: 3, Rules: DefaultRules()}); err == nil {
+		t.Error("Bots alone never move, so a humanless simulation should be refused")
+	}
+	if _, err := Simulate(SimConfig{Ticks: 10, Humans: 1, HumanStrategy: "nope", Rules: DefaultRules()}); err == nil {
+		t.Error("Expected an unknown strategy to be refused")
+	}
+}
+
+func TestSimStatsAdd(t *testing.T) {
+	var total SimStats
+	total.Add(SimStats{Ticks: 10, Deaths: map[DeathCause]int{CauseCrash: 1}, Lifespans: []int{4}, Scores: []int{10}})
+	total.Add(SimStats{Ticks: 10, Deaths: map[DeathCause]int{CauseCrash: 1}, Lifespans: []int{8}, Scores: []int{30}, PacManBites: 2})
+	if total.Deaths[CauseCrash] != 2 || total.AverageLifespan() != 6 || total.BiteRate() != 100 {
+		t.Errorf("Unexpected totals: %+v", total)
+	}
+	if total.ScorePercentile(0) != 10 || total.ScorePercentile(1) != 30 {
+		t.Errorf("Unexpected score percentiles")
+	}
+}
@@ -0,0 +1,185 @@
+package flow
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Strategy is a bot brain. Each tick the playfield hands it a View from its
+// bot's seat and moves the bot in the returned direction; Unknown keeps the
+// current heading. Decide runs on the playfield goroutine, so it must not
+// block, and the View is only valid for the duration of the call.
+type Strategy interface {
+	Decide(v *View) Direction
+}
+
+// StrategyFunc adapts a plain function to Strategy.
+type StrategyFunc func(v *View) Direction
+
+func (f StrategyFunc) Decide(v *View) Direction { return f(v) }
+
+// DefaultStrategy is what a bot runs when its room's BotMix doesn't say
+// otherwise.
+const DefaultStrategy = "classic"
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[string]Strategy{
+		"classic": StrategyFunc(classicStrategy),
+		"greedy":  StrategyFunc(greedyStrategy),
+		"planner": StrategyFunc(plannerStrategy),
+	}
+)
+
+// RegisterStrategy makes s available to BotMix under name. It panics if the
+// name is already taken, like database/sql.Register, so two packages can't
+// silently shadow each other's brains.
+func RegisterStrategy(name string, s Strategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	if s == nil {
+		panic("flow: RegisterStrategy with nil strategy")
+	}
+	if _, dup := strategies[name]; dup {
+		panic(fmt.Sprintf("flow: RegisterStrategy called twice for %q", name))
+	}
+	strategies[name] = s
+}
+
+// LookupStrategy returns the strategy registered under name.
+func LookupStrategy(name string) (Strategy, bool) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	s, ok := strategies[name]
+	return s, ok
+}
+
+// StrategyNames lists every registered strategy, sorted.
+func StrategyNames() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// View is a bot's read-only window on the playfield. Strategies outside
+// this package only get what its methods expose; the built-in ones reach
+// the underlying worm and field directly.
+type View struct {
+	w *Worm
+	p *Playfield
+}
+
+// Head is the bot's head cell.
+func (v *View) Head() Position { return v.w.Head() }
+
+// Heading is the direction the bot last moved in.
+func (v *View) Heading() Direction { return v.w.direction }
+
+// Body returns a copy of the bot's cells, head first.
+func (v *View) Body() []Position { return append([]Position(nil), v.w.blocks...) }
+
+// Personality is the bot's tuning. Bots without one (e.g. built by hand)
+// get a freshly drawn persona each call.
+func (v *View) Personality() AIPersonality {
+	if v.w.personality.Name == "" {
+		return newPersonality(v.p.rng, v.p.difficulty())
+	}
+	return v.w.personality
+}
+
+// SafeDirections lists the directions whose next cell won't kill the bot
+// (see safeDirections).
+func (v *View) SafeDirections() []Direction { return safeDirections(v.w, v.p) }
+
+// NearestFood is the food the classic brain would go for, if any.
+func (v *View) NearestFood() (Position, bool) { return nearestFood(v.w.Head(), v.p) }
+
+// Advance is the cell reached by moving d from pos, portals included.
+func (v *View) Advance(pos Position, d Direction) Position { return v.p.advance(pos, d) }
+
+// Distance is the portal-aware distance between two cells.
+func (v *View) Distance(a, b Position) int { return v.p.distance(a, b) }
+
+// Foods returns a copy of every food on the field.
+func (v *View) Foods() []Food {
+	out := make([]Food, 0, len(v.p.Foods))
+	for _, f := range v.p.sortedFoods() {
+		out = append(out, *f)
+	}
+	return out
+}
+
+// BotShare is one strategy's slice of a room's bots.
+type BotShare struct {
+	Strategy string
+	Weight   int
+}
+
+// BotMix is how a room splits its bots between strategies. New bots go to
+// whichever share is furthest below its weight, so the mix holds as bots
+// come and go.
+type BotMix []BotShare
+
+// Validate rejects unregistered strategies and non-positive weights.
+func (m BotMix) Validate() error {
+	for _, s := range m {
+		if _, ok := LookupStrategy(s.Strategy); !ok {
+			return fmt.Errorf("unknown bot strategy %q", s.Strategy)
+		}
+		if s.Weight <= 0 {
+			return fmt.Errorf("bot strategy %q: weight must be positive", s.Strategy)
+		}
+	}
+	return nil
+}
+
+// ParseBotMix reads a mix written as comma-separated strategy:weight
+// pairs, such as "classic:2,greedy". A missing weight is 1.
+func ParseBotMix(raw string) (BotMix, error) {
+	var m BotMix
+	for _, part := range SplitList(raw) {
+		name, weight, ok := strings.Cut(part, ":")
+		share := BotShare{Strategy: strings.TrimSpace(name), Weight: 1}
+		if ok {
+			n, err := strconv.Atoi(strings.TrimSpace(weight))
+			if err != nil {
+				return nil, fmt.Errorf("bot strategy %q: weight %q is not a number", share.Strategy, weight)
+			}
+			share.Weight = n
+		}
+		m = append(m, share)
+	}
+	return m, m.Validate()
+}
+
+// pick chooses the strategy for the next bot given how many bots already
+// run each one. An empty mix, or one with nothing usable, yields
+// DefaultStrategy.
+func (m BotMix) pick(running map[string]int) string {
+	best := ""
+	bestLoad := 0.0
+	for _, s := range m {
+		if s.Weight <= 0 {
+			continue
+		}
+		if _, ok := LookupStrategy(s.Strategy); !ok {
+			continue
+		}
+		load := float64(running[s.Strategy]+1) / float64(s.Weight)
+		if best == "" || load < bestLoad {
+			best, bestLoad = s.Strategy, load
+		}
+	}
+	if best == "" {
+		return DefaultStrategy
+	}
+	return best
+}
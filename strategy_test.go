@@ -0,0 +1,72 @@
+package flow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuiltinStrategiesRegistered(t *testing.T) {
+	for _, name := range []string{"classic", "greedy"} {
+		if _, ok := LookupStrategy(name); !ok {
+			t.Errorf("Expected built-in strategy %q", name)
+		}
+	}
+}
+
+func TestRegisterStrategyRejectsDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Registering a taken name should panic")
+		}
+	}()
+	RegisterStrategy(DefaultStrategy, StrategyFunc(greedyStrategy))
+}
+
+func TestCustomStrategyDrivesBot(t *testing.T) {
+	RegisterStrategy("test-always-up", StrategyFunc(func(v *View) Direction { return Up }))
+	p := NewPlayfield()
+	w := NewWorm()
+	w.AI = true
+	w.strategy = "test-always-up"
+	p.addMovable(w)
+
+	if d := pickAIDirection(w, p); d != Up {
+		t.Errorf("Expected the bot's own strategy to decide, got %v", d)
+	}
+}
+
+func TestBotMixKeepsProportions(t *testing.T) {
+	mix := BotMix{{Strategy: "classic", Weight: 2}, {Strategy: "greedy", Weight: 1}}
+	running := map[string]int{}
+	for i := 0; i < 6; i++ {
+		running[mix.pick(running)]++
+	}
+	if running["classic"] != 4 || running["greedy"] != 2 {
+		t.Errorf("Expected a 2:1 split, got %v", running)
+	}
+}
+
+func TestBotMixValidate(t *testing.T) {
+	if err := (BotMix{{Strategy: "no-such-brain", Weight: 1}}).Validate(); err == nil {
+		t.Errorf("Unknown strategies should be rejected")
+	}
+	if err := DefaultRules().BotMix.Validate(); err != nil {
+		t.Errorf("Default mix rejected: %v", err)
+	}
+}
+
+func TestParseBotMix(t *testing.T) {
+	mix, err := ParseBotMix("classic:2, greedy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := BotMix{{Strategy: "classic", Weight: 2}, {Strategy: "greedy", Weight: 1}}
+	if !reflect.DeepEqual(mix, want) {
+		t.Errorf("Expected %v, got %v", want, mix)
+	}
+	for _, bad := range []string{"classic:lots", "classic:0", "no-such-brain"} {
+		if _, err := ParseBotMix(bad); err == nil {
+			t.Errorf("Expected %q refused", bad)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// TuneConfig drives Evolve.
+type TuneConfig struct {
+	Seed        uint64
+	Population  int // genomes per generation; at least BotsPerMatch
+	Generations int
+	// Matches is how many headless matches each generation plays. Every
+	// match seats BotsPerMatch genomes, dealt round-robin from a shuffle,
+	// so each genome plays about Matches*BotsPerMatch/Population of them.
+	Matches      int
+	Ticks        int // per match
+	BotsPerMatch int
+	// Survival is the fitness worth of 100 ticks alive, next to one point
+	// per point scored.
+	Survival float64
+	// Keep is how many of the final generation's best genomes Evolve
+	// returns.
+	Keep int
+	// Rules the matches run with; Personas is overwritten per match.
+	Rules Rules
+	// Progress, if set, hears about the best genome of each generation.
+	Progress func(generation int, best AIPersonality, fitness float64)
+}
+
+// traitBounds is the envelope of every difficulty preset's ranges: the
+// space genomes are drawn and mutated in.
+func traitBounds() DifficultyPreset {
+	var b DifficultyPreset
+	first := true
+	widen := func(dst *Range, r Range) {
+		if first || r.Min < dst.Min {
+			dst.Min = r.Min
+		}
+		if first || r.Max > dst.Max {
+			dst.Max = r.Max
+		}
+	}
+	for _, d := range []Difficulty{Easy, Normal, Hard, Nightmare} {
+		p := d.Preset()
+		widen(&b.FoodPull, p.FoodPull)
+		widen(&b.Inertia, p.Inertia)
+		widen(&b.CenterPull, p.CenterPull)
+		widen(&b.HesitationRate, p.HesitationRate)
+		widen(&b.MistakeRate, p.MistakeRate)
+		widen(&b.PacManFear, p.PacManFear)
+		first = false
+	}
+	return b
+}
+
+// genes lists a personality's tunable traits alongside their bounds, so
+// crossover and mutation can treat them alike.
+func genes(p *AIPersonality, b *DifficultyPreset) []struct {
+	v *float64
+	r Range
+} {
+	return []struct {
+		v *float64
+		r Range
+	}{
+		{&p.FoodPull, b.FoodPull},
+		{&p.Inertia, b.Inertia},
+		{&p.CenterPull, b.CenterPull},
+		{&p.HesitationRate, b.HesitationRate},
+		{&p.MistakeRate, b.MistakeRate},
+		{&p.PacManFear, b.PacManFear},
+	}
+}
+
+var (
+	personaAdjectives = []string{"Sly", "Swift", "Grim", "Lucky", "Lazy", "Bold", "Shy", "Mad", "Wise", "Wild", "Calm", "Keen"}
+	personaAnimals    = []string{"Viper", "Mamba", "Adder", "Cobra", "Python", "Boa", "Krait", "Racer", "Taipan", "Asp", "Eel", "Newt"}
+)
+
+// Evolve breeds bot personas by playing headless matches (see Simulate)
+// between them. Each generation the better half by fitness — points
+// scored plus Survival per 100 ticks alive, averaged over the matches a
+// genome played — survives as is, and the rest is replaced by children
+// of two survivors: each trait from either parent, then nudged by
+// gaussian noise within traitBounds. Returns the Keep fittest genomes of
+// the last generation, best first, ready for SavePersonas.
+func Evolve(cfg TuneConfig) (Personas, error) {
+	if cfg.BotsPerMatch < 1 || cfg.Population < cfg.BotsPerMatch {
+		return nil, errors.New("population must be at least as big as the bots per match, which must be positive")
+	}
+	if cfg.Generations < 1 || cfg.Matches < 1 || cfg.Ticks < 1 {
+		return nil, errors.New("generations, matches and ticks must be positive")
+	}
+	rng := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x2545F4914F6CDD1D))
+	bounds := traitBounds()
+
+	used := map[string]bool{}
+	name := func() string {
+		for {
+			n := personaAdjectives[rng.IntN(len(personaAdjectives))] + personaAnimals[rng.IntN(len(personaAnimals))]
+			if !used[n] {
+				used[n] = true
+				return n
+			}
+			if len(used) >= len(personaAdjectives)*len(personaAnimals) {
+				n = fmt.Sprintf("%s-%d", n, len(used))
+				used[n] = true
+				return n
+			}
+		}
+	}
+
+	population := make(Personas, cfg.Population)
+	for i := range population {
+		g := AIPersonality{Name: name(), Lookahead: rng.IntN(2) == 0}
+		for _, gene := range genes(&g, &bounds) {
+			*gene.v = gene.r.draw(rng)
+		}
+		population[i] = g
+	}
+
+	type ranked struct {
+		genome  AIPersonality
+		fitness float64
+	}
+	var board []ranked
+	for gen := 0; gen < cfg.Generations; gen++ {
+		var stats SimStats
+		order := rng.Perm(len(population))
+		for m := 0; m < cfg.Matches; m++ {
+			seated := make(Personas, 0, cfg.BotsPerMatch)
+			for i := 0; i < cfg.BotsPerMatch; i++ {
+				seated = append(seated, population[order[(m*cfg.BotsPerMatch+i)%len(order)]])
+			}
+			rules := cfg.Rules
+			rules.Personas = seated
+			s, err := Simulate(SimConfig{
+				Seed:   rng.Uint64(),
+				Ticks:  cfg.Ticks,
+				Bots:   cfg.BotsPerMatch,
+				Humans: 1,
+				Rules:  rules,
+			})
+			if err != nil {
+				return nil, err
+			}
+			stats.Add(s)
+		}
+
+		board = board[:0]
+		for _, g := range population {
+			b := stats.Bots[g.Name]
+			f := 0.0
+			if b.Matches > 0 {
+				f = (float64(b.Points) + cfg.Survival*float64(b.TicksAlive)/100) / float64(b.Matches)
+			}
+			board = append(board, ranked{g, f})
+		}
+		sort.SliceStable(board, func(i, j int) bool { return board[i].fitness > board[j].fitness })
+		if cfg.Progress != nil {
+			cfg.Progress(gen, board[0].genome, board[0].fitness)
+		}
+		if gen == cfg.Generations-1 {
+			break
+		}
+
+		survivors := (len(board) + 1) / 2
+		next := make(Personas, 0, len(population))
+		for _, r := range board[:survivors] {
+			next = append(next, r.genome)
+		}
+		for len(next) < len(population) {
+			a, b := board[rng.IntN(survivors)].genome, board[rng.IntN(survivors)].genome
+			child := AIPersonality{Name: name(), Lookahead: a.Lookahead}
+			if rng.IntN(2) == 0 {
+				child.Lookahead = b.Lookahead
+			}
+			if rng.Float64() < 0.1 {
+				child.Lookahead = !child.Lookahead
+			}
+			ga, gb := genes(&a, &bounds), genes(&b, &bounds)
+			for i, gene := range genes(&child, &bounds) {
+				v := *ga[i].v
+				if rng.IntN(2) == 0 {
+					v = *gb[i].v
+				}
+				v += rng.NormFloat64() * (gene.r.Max - gene.r.Min) / 10
+				*gene.v = math.Max(gene.r.Min, math.Min(gene.r.Max, v))
+			}
+			next = append(next, child)
+		}
+		population = next
+	}
+
+	keep := cfg.Keep
+	if keep <= 0 || keep > len(board) {
+		keep = len(board)
+	}
+	out := make(Personas, 0, keep)
+	for _, r := range board[:keep] {
+		out = append(out, r.genome)
+	}
+	return out, nil
+}
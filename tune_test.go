@@ -0,0 +1,92 @@
+package flow
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEvolveReturnsValidPersonas(t *testing.T) {
+	generations := 0
+	ps, err := Evolve(TuneConfig{
+		Seed:         1,
+		Population:   6,
+		Generations:  2,
+		Matches:      2,
+		Ticks:        200,
+		BotsPerMatch: 3,
+		Survival:     5,
+		Keep:         4,
+		Rules:        DefaultRules(),
+		Progress:     func(int, AIPersonality, float64) { generations++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if generations != 2 {
+		t.Errorf("Expected progress for 2 generations, got %d", generations)
+	}
+	if len(ps) != 4 {
+		t.Fatalf("Expected 4 personas, got %d", len(ps))
+	}
+	if err := ps.Validate(); err != nil {
+		t.Error(err)
+	}
+	b := traitBounds()
+	for _, p := range ps {
+		if p.FoodPull < b.FoodPull.Min || p.FoodPull > b.FoodPull.Max {
+			t.Errorf("%s: FoodPull %v outside %v", p.Name, p.FoodPull, b.FoodPull)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "personas.json")
+	if err := SavePersonas(path, ps); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPersonas(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(ps) || loaded[0] != ps[0] {
+		t.Errorf("Personas should survive a save and load")
+	}
+}
+
+func TestBotsTakeFreePersonasFirst(t *testing.T) {
+	p := NewSeededPlayfield(1)
+	p.Rules.Personas = Personas{
+		{Name: "SlyViper", FoodPull: 2, Inertia: 1},
+		{Name: "CalmBoa", FoodPull: 1, Inertia: 2},
+	}
+	for i := 0; i < 3; i++ {
+		p.spawnAI()
+	}
+	named := map[string]bool{}
+	for token, w := range p.Tokens {
+		if !isAIToken(token) {
+			t.Errorf("Bot %s has a token a client could claim: %q", w.Name, token)
+		}
+		named[w.Name] = true
+	}
+	if !named["SlyViper"] || !named["CalmBoa"] || len(named) != 3 {
+		t.Errorf("Expected both personas and one drawn bot, got %v", named)
+	}
+
+	p.Rules.AdaptiveDifficulty = true
+	p.adaptDifficulty()
+	if w := p.Tokens[aiTokenPrefix+"SlyViper"]; w.personality.FoodPull != 2 || w.Name != "SlyViper" {
+		t.Errorf("Adaptive difficulty should leave persona bots alone, got %+v", w.personality)
+	}
+}
+
+func TestPersonasValidate(t *testing.T) {
+	for _, ps := range []Personas{
+		{{Name: ""}},
+		{{Name: "A"}, {Name: "A"}},
+		{{Name: "A", FoodPull: -1}},
+		{{Name: "A", MistakeRate: 2}},
+	} {
+		if ps.Validate() == nil {
+			t.Errorf("Expected %+v to be rejected", ps)
+		}
+	}
+}
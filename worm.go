@@ -1,6 +1,9 @@
 package flow
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 const (
 	Boundary       = 49 // The outer boundary of a playfield
@@ -8,6 +11,28 @@ const (
 	GrowthInterval = 30 // Points between each tail-growth step
 )
 
+// Combo tuning. Eating again within Window ticks of the previous eat
+// raises the worm's multiplier by one, up to MaxMultiplier; letting the
+// window run out or getting bitten drops it back to 1.
+type Combo struct {
+	Window        int
+	MaxMultiplier int
+}
+
+// The default Combo.
+const (
+	ComboWindow   = 15 // 3s at the default Tick
+	MaxMultiplier = 5
+)
+
+// Validate rejects a negative window or a multiplier cap below 1.
+func (c Combo) Validate() error {
+	if c.Window < 0 || c.MaxMultiplier < 1 {
+		return errors.New("Combo.Window must not be negative and Combo.MaxMultiplier must be at least 1")
+	}
+	return nil
+}
+
 type Length uint
 
 type Direction uint
@@ -20,6 +45,21 @@ const (
 	Right
 )
 
+// DeathCause is the machine-readable counterpart of a worm's death reason.
+// Clients key icons and kill-feed wording off it; the reason string stays
+// the human-readable text for the GAMEOVER dialog.
+type DeathCause string
+
+const (
+	CauseSelf   DeathCause = "self"    // ran into its own body
+	CauseHeadOn DeathCause = "head_on" // two or more heads met on one cell
+	CauseCrash  DeathCause = "crash"   // head ran into another worm's body
+	CauseBomb   DeathCause = "bomb"    // stepped on a bomb
+	CausePacMan DeathCause = "pacman"  // head bitten by Pac-Man
+	CauseBlast  DeathCause = "blast"   // head caught in a time bomb's blast
+	CauseFood   DeathCause = "food"    // ate a lethal food kind without its own Cause
+)
+
 func (d Direction) String() string {
 	switch d {
 	case Up:
@@ -54,9 +94,14 @@ type Worm struct {
 	// Identity persisted across reconnects.
 	Token string
 
-	// AI-controlled worms are driven by the server each tick.
+	// AI-controlled worms are driven by the server each tick. Bot worms
+	// are driven by an external program through the bot API; to the
+	// playfield they are players like any human.
 	AI          bool
+	Bot         bool
 	personality AIPersonality
+	// strategy is the registry name of the Strategy driving a bot.
+	strategy string
 
 	// connected is true while a human-owned worm has a live websocket.
 	// Used to gate AI ticking: bots stay still when no human is online.
@@ -68,15 +113,37 @@ type Worm struct {
 	// Ticks the AI has been dead — used to auto-respawn.
 	aiDeadTicks int
 
+	// protection counts down the ticks of spawn protection left. While it
+	// is non-zero the worm can't be bitten by Pac-Man and takes no part in
+	// snake-on-snake collisions, in either direction.
+	protection int
+	// respawnCooldown counts down the ticks until a RESPAWN is honoured
+	// again. A RESPAWN that arrives early sets respawnPending and is
+	// applied by the tick once the cooldown runs out.
+	respawnCooldown int
+	respawnPending  bool
+
+	// portals maps each portal cell to its partner. Shared with the
+	// playfield, which sets it when the worm joins; nil means no portals.
+	portals map[Position]Position
+
 	// Player-visible state
 	Name            string
 	Score           int
 	lastGrowthScore int
 	pendingGrowth   int
+	// multiplier scales food points while a streak lasts; comboTicks
+	// counts down the window in which the next eat extends it.
+	multiplier int
+	comboTicks int
 
 	// Dead worms stop ticking; the client gets a GAMEOVER and may RESPAWN.
+	// killer is the worm credited with the kill (crash / head-on), nil for
+	// self-inflicted and environmental deaths.
 	killed      bool
 	deathReason string
+	deathCause  DeathCause
+	killer      *Worm
 }
 
 func NewWorm() *Worm {
@@ -100,8 +167,23 @@ func (w *Worm) Head() Position {
 	return w.blocks[0]
 }
 
-func (w *Worm) Killed() bool        { return w.killed }
-func (w *Worm) DeathReason() string { return w.deathReason }
+func (w *Worm) Killed() bool           { return w.killed }
+func (w *Worm) DeathReason() string    { return w.deathReason }
+func (w *Worm) DeathCause() DeathCause { return w.deathCause }
+func (w *Worm) Protected() bool        { return w.protection > 0 }
+func (w *Worm) Strategy() string       { return w.strategy }
+
+// isHuman reports whether w is played by a person: neither a server AI
+// nor an external program on the bot API. Only humans keep a room going.
+func isHuman(w *Worm) bool { return !w.AI && !w.Bot }
+
+// die marks the worm dead. killer may be nil.
+func (w *Worm) die(cause DeathCause, reason string, killer *Worm) {
+	w.killed = true
+	w.deathCause = cause
+	w.deathReason = reason
+	w.killer = killer
+}
 
 // Kill terminates the websocket transport.
 func (w *Worm) Kill() {
@@ -125,6 +207,70 @@ func (w *Worm) AddScore(points int) int {
 	return grown
 }
 
+// cut truncates the body at segment i (i > 0), as a bite or a blast does,
+// and returns the cells it lost. The combo streak ends with it.
+func (w *Worm) cut(i int) []Position {
+	lost := append([]Position(nil), w.blocks[i:]...)
+	w.blocks = w.blocks[:i]
+	// Lose growth credit. Score is left alone (the player keeps the
+	// points) but the next growth has to be re-earned from scratch by
+	// scoring another GrowthInterval. Setting lastGrowthScore to the
+	// current score zeroes the partial-credit window — any food
+	// progress toward the next growth is forfeited, which is the right
+	// penalty: a bite that drops N segments should cost N×GrowthInterval
+	// to recover, not less.
+	w.lastGrowthScore = w.Score
+	// In-flight growth from a recent fruit is forfeited too — the worm
+	// just got shorter, queueing more growth would look like the bite
+	// didn't take.
+	w.pendingGrowth = 0
+	w.breakCombo()
+	return lost
+}
+
+// Multiplier is the current combo multiplier, at least 1.
+func (w *Worm) Multiplier() int {
+	if w.multiplier < 1 {
+		return 1
+	}
+	return w.multiplier
+}
+
+// Eat credits points for a food, scaled by the combo multiplier, and
+// extends the streak as combo says. Returns the points actually credited
+// and the number of segments queued, like AddScore.
+func (w *Worm) Eat(points int, combo Combo) (int, int) {
+	if w.comboTicks > 0 && w.multiplier < combo.MaxMultiplier {
+		w.multiplier = w.Multiplier() + 1
+	} else if w.comboTicks == 0 {
+		w.multiplier = 1
+	}
+	w.comboTicks = combo.Window
+	credited := points * w.multiplier
+	return credited, w.AddScore(credited)
+}
+
+// tickCombo runs the streak window down by one tick. Reports whether the
+// streak just ended, so the caller can tell clients the multiplier reset.
+func (w *Worm) tickCombo() bool {
+	if w.comboTicks == 0 {
+		return false
+	}
+	w.comboTicks--
+	if w.comboTicks > 0 {
+		return false
+	}
+	ended := w.multiplier > 1
+	w.multiplier = 1
+	return ended
+}
+
+// breakCombo ends the streak at once, e.g. when Pac-Man takes a bite.
+func (w *Worm) breakCombo() {
+	w.multiplier = 1
+	w.comboTicks = 0
+}
+
 // Reset returns the worm to its starting state — used on RESPAWN.
 func (w *Worm) Reset() {
 	blocks := make([]Position, WormSize)
@@ -137,23 +283,18 @@ func (w *Worm) Reset() {
 	w.Score = 0
 	w.lastGrowthScore = 0
 	w.pendingGrowth = 0
+	w.breakCombo()
 	w.killed = false
 	w.deathReason = ""
+	w.deathCause = ""
+	w.killer = nil
+	w.protection = 0
+	w.respawnPending = false
 }
 
-// Direction returns the current heading. A freshly-spawned worm picks a
-// random starting direction so the game loop always advances.
+// Direction returns the current heading. It is Unknown until the worm's
+// first move; the playfield then picks one from its seeded source.
 func (w *Worm) Direction() Direction {
-	if w.direction == Unknown {
-		choice := make(chan Direction, 1)
-		select {
-		case choice <- Right:
-		case choice <- Left:
-		case choice <- Down:
-		case choice <- Up:
-		}
-		w.direction = <-choice
-	}
 	return w.direction
 }
 
@@ -192,6 +333,12 @@ func (w *Worm) Move(d Direction) {
 		next.Y = 0
 	}
 
+	// Through a portal: the head comes out on the partner cell, heading
+	// unchanged. The body follows because it retraces the head's cells.
+	if exit, ok := w.portals[next]; ok {
+		next = exit
+	}
+
 	// Self-collision. The tail block will vacate this tick if no growth is
 	// pending, so colliding with the last block is forgiven in that case.
 	checkBlocks := w.blocks
@@ -200,8 +347,7 @@ func (w *Worm) Move(d Direction) {
 	}
 	for _, b := range checkBlocks {
 		if b == next {
-			w.killed = true
-			w.deathReason = "Ate yourself"
+			w.die(CauseSelf, "Ate yourself", nil)
 			return
 		}
 	}
@@ -5,8 +5,8 @@ import "testing"
 func TestWormMovability(t *testing.T) {
 	w := NewWorm()
 
-	if d := w.Direction(); d == Unknown {
-		t.Errorf("Direction() should auto-pick on first call")
+	if d := w.Direction(); d != Unknown {
+		t.Errorf("A fresh worm should have no heading yet, got %v", d)
 	}
 
 	originalPos := w.blocks[0]
@@ -78,3 +78,53 @@ func TestMoveCmdDispatch(t *testing.T) {
 		t.Errorf("Reversal should not be allowed")
 	}
 }
+
+func TestComboRaisesMultiplier(t *testing.T) {
+	w := NewWorm()
+	if got, _ := w.Eat(10, DefaultRules().Combo); got != 10 {
+		t.Errorf("First eat should be unmultiplied, got %d", got)
+	}
+	if got, _ := w.Eat(10, DefaultRules().Combo); got != 20 {
+		t.Errorf("Second eat inside the window should double, got %d", got)
+	}
+	for i := 0; i < 10; i++ {
+		w.Eat(10, DefaultRules().Combo)
+	}
+	if w.Multiplier() != MaxMultiplier {
+		t.Errorf("Multiplier should cap at %d, got %d", MaxMultiplier, w.Multiplier())
+	}
+}
+
+func TestComboResetsWhenIdle(t *testing.T) {
+	w := NewWorm()
+	w.Eat(10, DefaultRules().Combo)
+	w.Eat(10, DefaultRules().Combo)
+	ended := false
+	for i := 0; i < ComboWindow; i++ {
+		ended = w.tickCombo() || ended
+	}
+	if !ended || w.Multiplier() != 1 {
+		t.Errorf("Streak should end after %d idle ticks, multiplier %d", ComboWindow, w.Multiplier())
+	}
+	if got, _ := w.Eat(10, DefaultRules().Combo); got != 10 {
+		t.Errorf("Eat after the window should be unmultiplied, got %d", got)
+	}
+}
+
+func TestComboGrowthUsesMultipliedScore(t *testing.T) {
+	w := NewWorm()
+	w.Eat(10, DefaultRules().Combo)
+	if _, grown := w.Eat(10, DefaultRules().Combo); grown != 1 {
+		t.Errorf("30 multiplied points should queue one segment, got %d", grown)
+	}
+}
+
+func TestBiteBreaksCombo(t *testing.T) {
+	w := NewWorm()
+	w.Eat(10, DefaultRules().Combo)
+	w.Eat(10, DefaultRules().Combo)
+	w.breakCombo()
+	if got, _ := w.Eat(10, DefaultRules().Combo); got != 10 {
+		t.Errorf("Eat after a bite should be unmultiplied, got %d", got)
+	}
+}